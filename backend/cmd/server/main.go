@@ -7,17 +7,22 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 
+	"note-llm/internal/app"
+	"note-llm/internal/db"
 	"note-llm/internal/httpserver"
+	"note-llm/internal/indexing"
+	"note-llm/internal/outbox"
+	"note-llm/internal/tokenizer"
 )
 
 func main() {
-	srv := httpserver.New()
 	err := godotenv.Load()
 	if err != nil {
 		log.Fatalf("Error loading .env file")
@@ -29,6 +34,16 @@ func main() {
 	addr := fmt.Sprintf(":%s", port)
 
 	httpserver.SetupAuthProviders()
+	tokenizer.Init()
+
+	deps, err := app.New()
+	if err != nil {
+		log.Fatalf("Failed to set up dependencies: %v", err)
+	}
+	if deps.InMemory {
+		log.Println("Using in-memory storage; notes are lost on exit")
+	}
+	srv := httpserver.New(deps)
 
 	server := &http.Server{
 		Addr:         addr,
@@ -38,6 +53,15 @@ func main() {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	var indexer *outbox.Pool
+	var jobs sync.WaitGroup
+	if !deps.InMemory {
+		indexer = startMongoBackground(bgCtx, &jobs)
+	}
+	deps.StartJobs(bgCtx, &jobs)
+
 	go func() {
 		log.Println("Starting server on :8080")
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -57,4 +81,45 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
+
+	// Unfinished index work stays in the outbox and resumes on next start.
+	// Everything that uses the connections has to stop before they close.
+	stopBackground()
+	if indexer != nil {
+		indexer.Wait()
+	}
+	jobs.Wait()
+
+	if err := deps.Close(ctx); err != nil {
+		log.Printf("Failed to close connections: %v", err)
+	}
+}
+
+// startMongoBackground prepares MongoDB and starts the jobs that only it
+// needs: the outbox indexer and the legacy vector backfill. The backfill
+// is tracked by jobs.
+func startMongoBackground(bgCtx context.Context, jobs *sync.WaitGroup) *outbox.Pool {
+	indexCtx, cancelIndexes := context.WithTimeout(context.Background(), 30*time.Second)
+	if err := db.EnsureIndexes(indexCtx); err != nil {
+		log.Printf("Failed to ensure MongoDB indexes: %v", err)
+	}
+	if n, err := db.DropStoredEmbeddings(indexCtx); err != nil {
+		log.Printf("Failed to drop stored embeddings: %v", err)
+	} else if n > 0 {
+		log.Printf("Dropped stored embeddings from %d notes", n)
+	}
+	cancelIndexes()
+
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		n, err := indexing.BackfillLegacyPoints(bgCtx)
+		if err != nil && bgCtx.Err() == nil {
+			log.Printf("Failed to backfill legacy vectors: %v", err)
+		} else if n > 0 {
+			log.Printf("Queued %d notes with legacy vectors for re-indexing", n)
+		}
+	}()
+
+	return outbox.Start(bgCtx, outbox.ConfigFromViper())
 }
@@ -0,0 +1,153 @@
+// Package app holds the dependencies the server shares: storage clients
+// are opened once here, checked through HealthChecks and closed on
+// shutdown.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"note-llm/internal/chunking"
+	"note-llm/internal/db"
+	"note-llm/internal/indexing"
+	"note-llm/internal/llm"
+	"note-llm/internal/qdrant"
+	"note-llm/internal/repository"
+	"note-llm/internal/trash"
+	"note-llm/internal/vectorstore"
+
+	"github.com/spf13/viper"
+)
+
+// Check probes one dependency and returns nil when it is usable.
+type Check struct {
+	Name  string
+	Probe func(ctx context.Context) error
+}
+
+// Container is built once by New in main and closed after the server has
+// stopped.
+type Container struct {
+	// InMemory is set when STORAGE_BACKEND=memory keeps everything in
+	// process memory, so there is no MongoDB to run the outbox on.
+	InMemory      bool
+	Notes         repository.NoteRepository
+	Users         repository.UserRepository
+	Notebooks     repository.NotebookRepository
+	Conversations repository.ConversationRepository
+	Vectors       vectorstore.VectorStore
+
+	checks  []Check
+	closers []func(ctx context.Context) error
+}
+
+// New opens the clients selected by STORAGE_BACKEND (mongo or memory) and
+// VECTOR_STORE. Connecting doesn't wait for the servers; use HealthChecks
+// to see whether they answer.
+//
+// In memory mode, notes are indexed as they are written. Unless configured
+// otherwise, vectors then stay in memory too and come from the offline
+// "hash" embedder, so the whole API works without external services.
+func New() (*Container, error) {
+	viper.SetDefault("STORAGE_BACKEND", "mongo")
+
+	c := &Container{}
+	switch backend := viper.GetString("STORAGE_BACKEND"); backend {
+	case "memory":
+		c.InMemory = true
+		if !viper.IsSet("EMBEDDING_PROVIDER") {
+			viper.Set("EMBEDDING_PROVIDER", "hash")
+		}
+		var err error
+		if viper.IsSet("VECTOR_STORE") {
+			c.Vectors, err = vectorstore.Default()
+		} else {
+			c.Vectors, err = vectorstore.OpenLocal("")
+		}
+		if err != nil {
+			return nil, err
+		}
+		notes := repository.NewMemoryNotes(c.Vectors, chunking.DefaultOptions())
+		c.Notes = notes
+		c.Users = repository.NewMemoryUsers()
+		c.Notebooks = repository.NewMemoryNotebooks(notes)
+		c.Conversations = repository.NewMemoryConversations()
+	case "mongo":
+		if _, err := db.Connect(); err != nil {
+			return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+		}
+		c.closers = append(c.closers, db.Disconnect)
+		c.checks = append(c.checks, Check{Name: "mongodb", Probe: db.Ping})
+		c.Notes = repository.NewMongoNotes()
+		c.Users = repository.NewMongoUsers()
+		c.Notebooks = repository.NewMongoNotebooks()
+		c.Conversations = repository.NewMongoConversations()
+
+		vectors, err := vectorstore.Default()
+		if err != nil {
+			return nil, errors.Join(err, c.Close(context.Background()))
+		}
+		c.Vectors = vectors
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (available: memory, mongo)", backend)
+	}
+
+	if _, ok := c.Vectors.(vectorstore.Qdrant); ok {
+		if _, err := qdrant.Connect(); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to connect to Qdrant: %w", err), c.Close(context.Background()))
+		}
+		c.closers = append(c.closers, func(context.Context) error { return qdrant.Close() })
+		c.checks = append(c.checks, Check{Name: "qdrant", Probe: qdrant.HealthCheck})
+	}
+
+	c.checks = append(c.checks,
+		Check{Name: "llm", Probe: llm.CheckChatModel},
+		Check{Name: "embeddings", Probe: llm.CheckEmbedder},
+	)
+	return c, nil
+}
+
+// HealthChecks returns a probe for each service in use: the databases and
+// the configured chat and embedding providers.
+func (c *Container) HealthChecks() []Check {
+	return c.checks
+}
+
+// StartJobs starts the jobs every backend needs: the reconciler, which
+// drops vectors of notes that no longer exist, and the trash purger. They
+// run until ctx is cancelled and are tracked by jobs.
+func (c *Container) StartJobs(ctx context.Context, jobs *sync.WaitGroup) {
+	viper.SetDefault("RECONCILE_INTERVAL", time.Hour)
+	viper.SetDefault("TRASH_RETENTION", 30*24*time.Hour)
+	viper.SetDefault("TRASH_PURGE_INTERVAL", time.Hour)
+
+	if interval := viper.GetDuration("RECONCILE_INTERVAL"); interval > 0 {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			indexing.RunReconciler(ctx, c.Vectors, c.Notes, interval)
+		}()
+	}
+
+	if interval := viper.GetDuration("TRASH_PURGE_INTERVAL"); interval > 0 {
+		retention := viper.GetDuration("TRASH_RETENTION")
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			trash.RunPurger(ctx, c.Notes, interval, retention)
+		}()
+	}
+}
+
+// Close closes the clients in the reverse order they were opened.
+func (c *Container) Close(ctx context.Context) error {
+	var errs []error
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		errs = append(errs, c.closers[i](ctx))
+	}
+	c.closers = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,234 @@
+package chunking
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"note-llm/internal/tokenizer"
+
+	"github.com/spf13/viper"
+)
+
+// Chunk is a contiguous slice of a note's content. Start and End are byte
+// offsets into the content, so the original text is content[Start:End].
+type Chunk struct {
+	Index   int
+	Heading string
+	Text    string
+	Start   int
+	End     int
+}
+
+type Options struct {
+	// MaxTokens is the largest chunk the splitter will emit.
+	MaxTokens int
+	// Overlap is how many tokens of the previous chunk are repeated at the
+	// start of the next one when a section has to be split.
+	Overlap int
+}
+
+func init() {
+	viper.SetDefault("CHUNK_MAX_TOKENS", 400)
+	viper.SetDefault("CHUNK_OVERLAP_TOKENS", 50)
+}
+
+// DefaultOptions reads CHUNK_MAX_TOKENS and CHUNK_OVERLAP_TOKENS. Indexers
+// call it once at startup and keep the result.
+func DefaultOptions() Options {
+	return Options{
+		MaxTokens: viper.GetInt("CHUNK_MAX_TOKENS"),
+		Overlap:   viper.GetInt("CHUNK_OVERLAP_TOKENS"),
+	}
+}
+
+var headingLine = regexp.MustCompile(`^ {0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+
+type block struct {
+	start, end int
+	heading    string
+	isHeading  bool
+	tokens     int
+}
+
+// Split cuts content into chunks of at most opts.MaxTokens tokens. It packs
+// whole paragraphs together, always starts a new chunk at a Markdown
+// heading, and falls back to overlapping token windows for paragraphs that
+// are too long on their own.
+func Split(content string, opts Options) []Chunk {
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultOptions().MaxTokens
+	}
+	if opts.Overlap < 0 || opts.Overlap >= opts.MaxTokens/2 {
+		opts.Overlap = opts.MaxTokens / 4
+	}
+
+	s := &splitter{content: content, opts: opts, start: -1}
+	for _, b := range blocks(content) {
+		switch {
+		case b.isHeading:
+			s.flush()
+			s.heading = b.heading
+			s.add(b)
+			s.headingOnly = true
+		case b.tokens > opts.MaxTokens:
+			// A lone heading is already carried in Chunk.Heading.
+			if s.headingOnly {
+				s.reset()
+			}
+			s.flush()
+			s.windows(b)
+		default:
+			if s.tokens+b.tokens > opts.MaxTokens && !s.headingOnly {
+				s.flushWithOverlap(b.tokens)
+			}
+			s.add(b)
+			s.headingOnly = false
+		}
+	}
+	s.flush()
+
+	return s.chunks
+}
+
+type splitter struct {
+	content string
+	opts    Options
+	chunks  []Chunk
+	heading string
+
+	start, end  int
+	tokens      int
+	headingOnly bool
+}
+
+func (s *splitter) add(b block) {
+	if s.start < 0 {
+		s.start = b.start
+	}
+	s.end = b.end
+	s.tokens += b.tokens
+}
+
+func (s *splitter) flush() {
+	if s.start >= 0 {
+		s.emit(s.start, s.end)
+	}
+	s.reset()
+}
+
+func (s *splitter) reset() {
+	s.start, s.end, s.tokens, s.headingOnly = -1, 0, 0, false
+}
+
+// flushWithOverlap emits the pending chunk and seeds the next one with its
+// last opts.Overlap tokens, as long as the next block still fits.
+func (s *splitter) flushWithOverlap(nextTokens int) {
+	if s.start < 0 {
+		return
+	}
+	prevStart, prevEnd := s.start, s.end
+	s.flush()
+
+	if s.opts.Overlap == 0 || nextTokens+s.opts.Overlap > s.opts.MaxTokens {
+		return
+	}
+	spans := tokenizer.Spans(s.content[prevStart:prevEnd])
+	if len(spans) <= s.opts.Overlap {
+		return
+	}
+	s.start = prevStart + spans[len(spans)-s.opts.Overlap].Start
+	s.end = prevEnd
+	s.tokens = s.opts.Overlap
+}
+
+// windows splits an oversized block into overlapping token windows.
+func (s *splitter) windows(b block) {
+	spans := tokenizer.Spans(s.content[b.start:b.end])
+	step := s.opts.MaxTokens - s.opts.Overlap
+	for i := 0; i < len(spans); i += step {
+		j := min(i+s.opts.MaxTokens, len(spans))
+		s.emit(b.start+spans[i].Start, b.start+spans[j-1].End)
+		if j == len(spans) {
+			break
+		}
+	}
+}
+
+func (s *splitter) emit(start, end int) {
+	start = snapForward(s.content, start)
+	end = snapForward(s.content, end)
+	text := s.content[start:end]
+
+	// Keep offsets pointing at the trimmed text.
+	trimmed := strings.TrimLeft(text, " \t\r\n")
+	start += len(text) - len(trimmed)
+	text = strings.TrimRight(trimmed, " \t\r\n")
+	end = start + len(text)
+	if text == "" {
+		return
+	}
+
+	s.chunks = append(s.chunks, Chunk{
+		Index:   len(s.chunks),
+		Heading: s.heading,
+		Text:    text,
+		Start:   start,
+		End:     end,
+	})
+}
+
+// blocks splits content into headings and blank-line separated paragraphs.
+func blocks(content string) []block {
+	var out []block
+	para := block{start: -1}
+	closePara := func() {
+		if para.start >= 0 {
+			para.tokens = tokenizer.Count(content[para.start:para.end])
+			out = append(out, para)
+		}
+		para = block{start: -1}
+	}
+
+	offset := 0
+	for offset < len(content) {
+		lineEnd := strings.IndexByte(content[offset:], '\n')
+		next := len(content)
+		if lineEnd >= 0 {
+			next = offset + lineEnd + 1
+		}
+		line := strings.TrimRight(content[offset:next], "\r\n")
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			closePara()
+		case headingLine.MatchString(line):
+			closePara()
+			out = append(out, block{
+				start:     offset,
+				end:       offset + len(line),
+				heading:   headingLine.FindStringSubmatch(line)[1],
+				isHeading: true,
+				tokens:    tokenizer.Count(line),
+			})
+		default:
+			if para.start < 0 {
+				para.start = offset
+			}
+			para.end = offset + len(line)
+		}
+		offset = next
+	}
+	closePara()
+
+	return out
+}
+
+// snapForward moves a byte offset to the next UTF-8 character start, since
+// BPE token boundaries may fall inside a multi-byte character.
+func snapForward(text string, offset int) int {
+	for offset < len(text) && !utf8.RuneStart(text[offset]) {
+		offset++
+	}
+	return offset
+}
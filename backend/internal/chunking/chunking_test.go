@@ -0,0 +1,31 @@
+package chunking
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitOffsets(t *testing.T) {
+	content := "# Bread\n\nMix the flour and water.\n\n## Rye\n\n" +
+		strings.Repeat("Rye dough is sticky and dense, so knead it wet. ", 40) +
+		"\n\n## Notes\n\nBake at 230°C — «crème brûlée» next."
+
+	chunks := Split(content, Options{MaxTokens: 60, Overlap: 10})
+	if len(chunks) < 3 {
+		t.Fatalf("got %d chunks, want the long section split", len(chunks))
+	}
+	for i, c := range chunks {
+		if c.Index != i {
+			t.Errorf("chunk %d has index %d", i, c.Index)
+		}
+		if c.Start < 0 || c.End > len(content) || c.Start >= c.End {
+			t.Fatalf("chunk %d has bad range [%d:%d]", i, c.Start, c.End)
+		}
+		if got := content[c.Start:c.End]; got != c.Text {
+			t.Errorf("chunk %d: content[%d:%d] = %q, Text = %q", i, c.Start, c.End, got, c.Text)
+		}
+	}
+	if last := chunks[len(chunks)-1]; last.Heading != "Notes" {
+		t.Errorf("last chunk heading = %q, want Notes", last.Heading)
+	}
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"note-llm/internal/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const conversationsCollection = "conversations"
+
+func InsertConversation(ctx context.Context, conv models.Conversation) error {
+	collection, err := mongoCollection(conversationsCollection)
+	if err != nil {
+		return err
+	}
+	_, err = collection.InsertOne(ctx, conv)
+	return err
+}
+
+// ListConversations returns a user's conversations without their messages,
+// most recently active first.
+func ListConversations(ctx context.Context, userID string) ([]models.Conversation, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetProjection(bson.M{"messages": 0})
+
+	collection, err := mongoCollection(conversationsCollection)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	conversations := []models.Conversation{}
+	if err := cursor.All(ctx, &conversations); err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// FetchConversation loads a conversation with its messages. It returns nil
+// without an error when it does not exist.
+func FetchConversation(ctx context.Context, id, userID string) (*models.Conversation, error) {
+	var conv models.Conversation
+	collection, err := mongoCollection(conversationsCollection)
+	if err != nil {
+		return nil, err
+	}
+	err = collection.
+		FindOne(ctx, bson.M{"_id": id, "user_id": userID}).
+		Decode(&conv)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// AppendConversationMessages adds turns to a conversation, setting its
+// title if it doesn't have one yet. It reports whether the conversation
+// was found.
+func AppendConversationMessages(ctx context.Context, id, userID, title string, messages ...models.ConversationMessage) (bool, error) {
+	collection, err := mongoCollection(conversationsCollection)
+	if err != nil {
+		return false, err
+	}
+	filter := bson.M{"_id": id, "user_id": userID}
+
+	result, err := collection.UpdateOne(ctx, filter, bson.M{
+		"$push": bson.M{"messages": bson.M{"$each": messages}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+	if err != nil || result.MatchedCount == 0 {
+		return false, err
+	}
+
+	if title != "" {
+		_, err = collection.UpdateOne(ctx, bson.M{"_id": id, "user_id": userID, "title": ""}, bson.M{"$set": bson.M{"title": title}})
+	}
+	return true, err
+}
+
+// DeleteConversation reports whether a conversation was deleted.
+func DeleteConversation(ctx context.Context, id, userID string) (bool, error) {
+	collection, err := mongoCollection(conversationsCollection)
+	if err != nil {
+		return false, err
+	}
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
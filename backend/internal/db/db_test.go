@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// mongoTest connects to the server at MONGODB_TEST_URI and skips the test
+// when it isn't set. Tests write to the app database there, so point it at
+// a disposable server.
+func mongoTest(t *testing.T) context.Context {
+	t.Helper()
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set")
+	}
+	viper.Set("MONGODB_URI", uri)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	if err := Ping(ctx); err != nil {
+		t.Fatalf("MongoDB at MONGODB_TEST_URI unavailable: %v", err)
+	}
+	return ctx
+}
+
+// testCollection returns a collection of the app database for a test that
+// called mongoTest.
+func testCollection(t *testing.T, name string) *mongo.Collection {
+	t.Helper()
+	collection, err := mongoCollection(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return collection
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// EnsureIndexes creates the indexes the queries in this package rely on.
+// Creating an index that already exists is a no-op.
+func EnsureIndexes(ctx context.Context) error {
+	database, err := GetMongoDatabase()
+	if err != nil {
+		return err
+	}
+
+	_, err = database.Collection("notes").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "modified_at", Value: -1}}},
+		// Trash listing and the retention job.
+		{Keys: bson.D{{Key: "deleted_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+		// Sort orders of ListNotes, with _id as the tie-breaker.
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "modified_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "notebook_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "tags", Value: 1}}},
+		{
+			// Keyword side of hybrid search; titles count three times as much.
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "content", Value: "text"}},
+			Options: options.Index().
+				SetName("notes_text").
+				SetWeights(bson.M{"title": 3, "content": 1}),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = database.Collection(outboxCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "dead", Value: 1}, {Key: "next_attempt_at", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = database.Collection(conversationsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = database.Collection(notebooksCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Unique so two concurrent updates can't both save revision N.
+	_, err = database.Collection(revisionsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "note_id", Value: 1}, {Key: "number", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
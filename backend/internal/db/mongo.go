@@ -1,21 +1,92 @@
 package db
 
 import (
+	"context"
+	"errors"
+	"sync"
+
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+)
+
+// ErrClosed is returned by Connect once Disconnect has been called.
+var ErrClosed = errors.New("mongodb client is closed")
+
+var (
+	clientMu sync.Mutex
+	client   *mongo.Client
+	closed   bool
 )
 
-func GetMongoDatabase() *mongo.Database {
-	uri := viper.GetString("MONGODB_URI")
+// Connect opens the MongoDB client that GetMongoDatabase shares, with a
+// connection pool sized by MONGODB_MAX_POOL_SIZE (the driver's default when
+// unset). Calling it again returns the open client.
+func Connect() (*mongo.Client, error) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	if closed {
+		return nil, ErrClosed
+	}
+	if client != nil {
+		return client, nil
+	}
+
+	opts := options.Client().ApplyURI(viper.GetString("MONGODB_URI"))
+	if size := viper.GetUint64("MONGODB_MAX_POOL_SIZE"); size > 0 {
+		opts.SetMaxPoolSize(size)
+	}
+	c, err := mongo.Connect(opts)
+	if err != nil {
+		return nil, err
+	}
+	client = c
+	return client, nil
+}
 
-	client, err := mongo.Connect(options.Client().
-		ApplyURI(uri))
+// Ping checks that the primary is reachable.
+func Ping(ctx context.Context) error {
+	c, err := Connect()
 	if err != nil {
-		panic(err)
+		return err
 	}
+	return c.Ping(ctx, readpref.Primary())
+}
+
+// Disconnect closes the shared client, waiting for in-use connections up
+// to ctx's deadline. It is meant for shutdown: the client is not reopened,
+// so later calls to Connect fail with ErrClosed.
+func Disconnect(ctx context.Context) error {
+	clientMu.Lock()
+	defer clientMu.Unlock()
 
-	db := client.Database("note-llm")
+	closed = true
+	if client == nil {
+		return nil
+	}
+	err := client.Disconnect(ctx)
+	client = nil
+	return err
+}
 
-	return db
+// GetMongoDatabase returns the app database on the shared client,
+// connecting on first use. It fails with ErrClosed after Disconnect, so
+// requests still running at shutdown get an error instead of a panic.
+func GetMongoDatabase() (*mongo.Database, error) {
+	c, err := Connect()
+	if err != nil {
+		return nil, err
+	}
+	return c.Database("note-llm"), nil
+}
+
+// mongoCollection returns a collection of the app database.
+func mongoCollection(name string) (*mongo.Collection, error) {
+	database, err := GetMongoDatabase()
+	if err != nil {
+		return nil, err
+	}
+	return database.Collection(name), nil
 }
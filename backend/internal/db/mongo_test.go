@@ -0,0 +1,25 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGetMongoDatabaseAfterDisconnect(t *testing.T) {
+	if err := Disconnect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		clientMu.Lock()
+		closed = false
+		clientMu.Unlock()
+	})
+
+	if _, err := GetMongoDatabase(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("GetMongoDatabase() error = %v, want ErrClosed", err)
+	}
+	if _, err := FetchNoteByID(context.Background(), "n1", "u1"); !errors.Is(err, ErrClosed) {
+		t.Errorf("FetchNoteByID() error = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"note-llm/internal/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const notebooksCollection = "notebooks"
+
+// ErrDuplicateNotebook is returned when a user already has a notebook with
+// the same name.
+var ErrDuplicateNotebook = errors.New("a notebook with this name already exists")
+
+func InsertNotebook(ctx context.Context, notebook models.Notebook) error {
+	collection, err := mongoCollection(notebooksCollection)
+	if err != nil {
+		return err
+	}
+	_, err = collection.InsertOne(ctx, notebook)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateNotebook
+	}
+	return err
+}
+
+// ListNotebooks returns a user's notebooks sorted by name.
+func ListNotebooks(ctx context.Context, userID string) ([]models.Notebook, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	collection, err := mongoCollection(notebooksCollection)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notebooks := []models.Notebook{}
+	if err := cursor.All(ctx, &notebooks); err != nil {
+		return nil, err
+	}
+	return notebooks, nil
+}
+
+// FetchNotebook returns nil without an error when the notebook does not
+// exist.
+func FetchNotebook(ctx context.Context, id, userID string) (*models.Notebook, error) {
+	var notebook models.Notebook
+	collection, err := mongoCollection(notebooksCollection)
+	if err != nil {
+		return nil, err
+	}
+	err = collection.
+		FindOne(ctx, bson.M{"_id": id, "user_id": userID}).
+		Decode(&notebook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &notebook, nil
+}
+
+// RenameNotebook reports whether the notebook was found.
+func RenameNotebook(ctx context.Context, id, userID, name string) (bool, error) {
+	collection, err := mongoCollection(notebooksCollection)
+	if err != nil {
+		return false, err
+	}
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID},
+		bson.M{"$set": bson.M{"name": name, "updated_at": time.Now()}},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, ErrDuplicateNotebook
+	}
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// DeleteNotebook deletes a notebook and moves its notes out of it. It
+// reports whether the notebook was found.
+func DeleteNotebook(ctx context.Context, id, userID string) (bool, error) {
+	var found bool
+	err := WithTransaction(ctx, func(ctx context.Context) error {
+		database, err := GetMongoDatabase()
+		if err != nil {
+			return err
+		}
+		result, err := database.Collection(notebooksCollection).DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+		if err != nil {
+			return err
+		}
+		found = result.DeletedCount > 0
+		if !found {
+			return nil
+		}
+		_, err = database.Collection("notes").UpdateMany(ctx,
+			bson.M{"user_id": userID, "notebook_id": id},
+			bson.M{"$unset": bson.M{"notebook_id": ""}, "$inc": bson.M{"version": 1}},
+		)
+		return err
+	})
+	return found, err
+}
@@ -2,35 +2,286 @@ package db
 
 import (
 	"context"
+	"errors"
+	"time"
+
 	"note-llm/internal/models"
 
-	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+// NoteQuery builds the MongoDB query for a user's notes matching f.
+// Notes in the trash never match.
+func NoteQuery(userID string, f models.NoteFilter) bson.M {
+	query := bson.M{"user_id": userID, "deleted_at": nil}
+	if f.NotebookID != "" {
+		query["notebook_id"] = f.NotebookID
+	}
+	if tags := models.NormalizeTags(f.Tags); len(tags) > 0 {
+		query["tags"] = bson.M{"$all": tags}
+	}
+	if len(f.NoteIDs) > 0 {
+		query["_id"] = bson.M{"$in": f.NoteIDs}
+	}
+	if r := timeRange(f.CreatedAfter, f.CreatedBefore); r != nil {
+		query["created_at"] = r
+	}
+	if r := timeRange(f.ModifiedAfter, f.ModifiedBefore); r != nil {
+		query["modified_at"] = r
+	}
+	return query
+}
+
+func timeRange(after, before *time.Time) bson.M {
+	if after == nil && before == nil {
+		return nil
+	}
+	r := bson.M{}
+	if after != nil {
+		r["$gte"] = *after
+	}
+	if before != nil {
+		r["$lt"] = *before
+	}
+	return r
+}
+
+// FetchNotesByIDs loads a user's notes in the order of noteIDs.
 func FetchNotesByIDs(ctx context.Context, noteIDs []string, userID string) ([]models.Note, error) {
-	collection := GetMongoDatabase().Collection("notes")
+	return FetchMatchingNotes(ctx, noteIDs, userID, models.NoteFilter{})
+}
 
-	filter := bson.M{
-		"user_id": userID,
-		"_id": bson.M{
-			"$in": noteIDs,
-		},
+// FetchMatchingNotes is FetchNotesByIDs, skipping notes that don't match f.
+func FetchMatchingNotes(ctx context.Context, noteIDs []string, userID string, f models.NoteFilter) ([]models.Note, error) {
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return nil, err
 	}
 
-	cursor, err := collection.Find(ctx, filter)
+	if len(f.NoteIDs) > 0 {
+		allowed := make(map[string]bool, len(f.NoteIDs))
+		for _, id := range f.NoteIDs {
+			allowed[id] = true
+		}
+		var both []string
+		for _, id := range noteIDs {
+			if allowed[id] {
+				both = append(both, id)
+			}
+		}
+		noteIDs = both
+	}
+	f.NoteIDs = noteIDs
+	if len(noteIDs) == 0 {
+		return []models.Note{}, nil
+	}
+
+	cursor, err := collection.Find(ctx, NoteQuery(userID, f))
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var notes []models.Note
+	byID := make(map[string]models.Note, len(noteIDs))
 	for cursor.Next(ctx) {
 		var note models.Note
 		if err := cursor.Decode(&note); err != nil {
 			return nil, err
 		}
-		notes = append(notes, note)
+		byID[note.ID] = note
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	// $in returns documents in storage order; restore the caller's (rank)
+	// order and skip IDs that no longer exist.
+	notes := make([]models.Note, 0, len(byID))
+	for _, id := range noteIDs {
+		if note, ok := byID[id]; ok {
+			notes = append(notes, note)
+			delete(byID, id)
+		}
 	}
 
 	return notes, nil
 }
+
+// SaveNoteIndexState persists the index status fields of a note after it
+// has been (re)indexed. It does nothing once the note has moved past the
+// indexed version: that edit queued its own entry, and its pending status
+// must not be overwritten with this one's.
+func SaveNoteIndexState(ctx context.Context, note models.Note) error {
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return err
+	}
+
+	set := bson.M{
+		"index_status": note.IndexStatus,
+		"index_error":  note.IndexError,
+	}
+	if note.IndexStatus == models.IndexStatusIndexed {
+		set["indexed_at"] = note.IndexedAt
+	}
+
+	// Vectors live in the vector store; drop the copy older versions kept
+	// here.
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"_id": note.ID, "user_id": note.UserID, "version": versionMatch(note.Version)},
+		bson.M{"$set": set, "$unset": bson.M{"embeddings": ""}},
+	)
+	return err
+}
+
+// ExistingNoteIDs reports which of the given note IDs still exist outside
+// the trash, across all users.
+func ExistingNoteIDs(ctx context.Context, noteIDs []string) (map[string]bool, error) {
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx,
+		bson.M{"_id": bson.M{"$in": noteIDs}, "deleted_at": nil},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	existing := make(map[string]bool, len(noteIDs))
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID string `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		existing[doc.ID] = true
+	}
+
+	return existing, cursor.Err()
+}
+
+func InsertNote(ctx context.Context, note models.Note) error {
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return err
+	}
+	_, err = collection.InsertOne(ctx, note)
+	return err
+}
+
+// FetchNoteByID loads a single note of a user. It returns nil without an
+// error when the note does not exist or is in the trash.
+func FetchNoteByID(ctx context.Context, noteID, userID string) (*models.Note, error) {
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return nil, err
+	}
+
+	var note models.Note
+	err = collection.FindOne(ctx, bson.M{"_id": noteID, "user_id": userID, "deleted_at": nil}).Decode(&note)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// versionMatch matches a note at version; notes without a version field
+// are at version 0.
+func versionMatch(version int64) any {
+	if version == 0 {
+		return nil
+	}
+	return version
+}
+
+// UpdateNoteVersion applies update to a note only if it is still at
+// version, and bumps the version. It reports whether the note matched.
+func UpdateNoteVersion(ctx context.Context, noteID, userID string, version int64, update bson.M) (bool, error) {
+	update["$inc"] = bson.M{"version": 1}
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return false, err
+	}
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": noteID, "user_id": userID, "deleted_at": nil, "version": versionMatch(version)},
+		update,
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// TextHit is a note matched by the full-text index, with MongoDB's
+// relevance score.
+type TextHit struct {
+	Note  models.Note
+	Score float64
+}
+
+// TextSearchNotes runs a full-text query over a user's notes, best match
+// first. Needs the text index created by EnsureIndexes.
+func TextSearchNotes(ctx context.Context, userID, query string, limit int64) ([]TextHit, error) {
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"user_id":    userID,
+		"deleted_at": nil,
+		"$text":      bson.M{"$search": query},
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}, "embeddings": 0}).
+		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetLimit(limit)
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var hits []TextHit
+	for cursor.Next(ctx) {
+		var doc struct {
+			models.Note `bson:",inline"`
+			Score       float64 `bson:"score"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		hits = append(hits, TextHit{Note: doc.Note, Score: doc.Score})
+	}
+
+	return hits, cursor.Err()
+}
+
+// DropStoredEmbeddings removes the note vectors older versions kept in
+// MongoDB; vectors now live only in the vector store. It returns how many
+// notes were cleaned up.
+func DropStoredEmbeddings(ctx context.Context) (int64, error) {
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return 0, err
+	}
+	result, err := collection.UpdateMany(ctx,
+		bson.M{"embeddings": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"embeddings": ""}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"note-llm/internal/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ErrInvalidCursor is returned for a cursor that is malformed or was issued
+// for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// noteSortFields maps the public sort keys to document fields.
+var noteSortFields = map[string]string{
+	models.NoteSortCreated:  "created_at",
+	models.NoteSortModified: "modified_at",
+	models.NoteSortTitle:    "title",
+}
+
+// noteCursor is the position after the last note of a page: its sort key
+// value and, to break ties, its ID.
+type noteCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// ListNotes returns one page of a user's notes, using keyset pagination on
+// (sort field, _id) so paging stays fast and stable while notes are added
+// or removed.
+func ListNotes(ctx context.Context, userID string, q models.NoteListQuery) (*models.NotePage, error) {
+	field, ok := noteSortFields[q.Sort]
+	if !ok {
+		return nil, errors.New("unknown sort")
+	}
+
+	query := NoteQuery(userID, q.Filter)
+	if q.Cursor != "" {
+		after, err := decodeNoteCursor(q.Cursor, q.Sort, q.Desc)
+		if err != nil {
+			return nil, err
+		}
+		op := "$gt"
+		if q.Desc {
+			op = "$lt"
+		}
+		query["$or"] = bson.A{
+			bson.M{field: bson.M{op: after.value}},
+			bson.M{field: after.value, "_id": bson.M{op: after.id}},
+		}
+	}
+
+	dir := 1
+	if q.Desc {
+		dir = -1
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: field, Value: dir}, {Key: "_id", Value: dir}}).
+		// Notes indexed before vectors lived only in Qdrant may still
+		// carry one.
+		SetProjection(bson.M{"embeddings": 0}).
+		SetLimit(int64(q.Limit + 1))
+
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notes := []models.Note{}
+	if err := cursor.All(ctx, &notes); err != nil {
+		return nil, err
+	}
+
+	page := &models.NotePage{}
+	if len(notes) > q.Limit {
+		notes = notes[:q.Limit]
+		page.NextCursor = encodeNoteCursor(notes[q.Limit-1], q.Sort, q.Desc)
+	}
+	page.Notes = models.NewNoteResponses(notes)
+	return page, nil
+}
+
+type cursorPosition struct {
+	value any
+	id    string
+}
+
+func encodeNoteCursor(last models.Note, sort string, desc bool) string {
+	c := noteCursor{Sort: sort, Desc: desc, ID: last.ID}
+	switch sort {
+	case models.NoteSortCreated:
+		c.Value = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case models.NoteSortModified:
+		c.Value = last.ModifiedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		c.Value = last.Title
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeNoteCursor(s, sort string, desc bool) (cursorPosition, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursorPosition{}, ErrInvalidCursor
+	}
+	var c noteCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != sort || c.Desc != desc || c.ID == "" {
+		return cursorPosition{}, ErrInvalidCursor
+	}
+
+	if sort == models.NoteSortTitle {
+		return cursorPosition{value: c.Value, id: c.ID}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return cursorPosition{}, ErrInvalidCursor
+	}
+	return cursorPosition{value: t, id: c.ID}, nil
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"note-llm/internal/models"
+)
+
+func TestNoteCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	note := models.Note{ID: "n1", Title: "Rye", CreatedAt: created}
+
+	pos, err := decodeNoteCursor(encodeNoteCursor(note, models.NoteSortCreated, true), models.NoteSortCreated, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.id != "n1" || !pos.value.(time.Time).Equal(created) {
+		t.Errorf("decoded %+v", pos)
+	}
+
+	pos, err = decodeNoteCursor(encodeNoteCursor(note, models.NoteSortTitle, false), models.NoteSortTitle, false)
+	if err != nil || pos.value != "Rye" {
+		t.Errorf("decoded %+v, %v", pos, err)
+	}
+}
+
+func TestDecodeNoteCursorRejects(t *testing.T) {
+	note := models.Note{ID: "n1", Title: "Rye"}
+	cursor := encodeNoteCursor(note, models.NoteSortTitle, false)
+
+	for name, tc := range map[string]struct {
+		cursor, sort string
+		desc         bool
+	}{
+		"garbage":    {"not base64!", models.NoteSortTitle, false},
+		"other sort": {cursor, models.NoteSortCreated, false},
+		"other dir":  {cursor, models.NoteSortTitle, true},
+	} {
+		if _, err := decodeNoteCursor(tc.cursor, tc.sort, tc.desc); err != ErrInvalidCursor {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-llm/internal/models"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestSaveNoteIndexStateIgnoresStaleVersion(t *testing.T) {
+	ctx := mongoTest(t)
+	note := models.Note{
+		ID:          uuid.New().String(),
+		UserID:      "u1",
+		Title:       "Rye",
+		IndexStatus: models.IndexStatusPending,
+		Version:     2,
+	}
+	if err := InsertNote(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		testCollection(t, "notes").DeleteOne(context.Background(), bson.M{"_id": note.ID})
+	})
+
+	now := time.Now()
+	indexed := note
+	indexed.IndexStatus = models.IndexStatusIndexed
+	indexed.IndexedAt = &now
+
+	// A worker that indexed version 1 finishes after version 2 was saved.
+	stale := indexed
+	stale.Version = 1
+	if err := SaveNoteIndexState(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+	got, err := FetchNoteByID(ctx, note.ID, note.UserID)
+	if err != nil || got == nil {
+		t.Fatalf("FetchNoteByID = %v, %v", got, err)
+	}
+	if got.IndexStatus != models.IndexStatusPending || got.IndexedAt != nil {
+		t.Fatalf("stale save overwrote status: %q at %v", got.IndexStatus, got.IndexedAt)
+	}
+
+	if err := SaveNoteIndexState(ctx, indexed); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = FetchNoteByID(ctx, note.ID, note.UserID); got.IndexStatus != models.IndexStatusIndexed {
+		t.Fatalf("current save left status %q", got.IndexStatus)
+	}
+}
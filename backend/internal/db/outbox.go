@@ -0,0 +1,227 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"note-llm/internal/models"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	outboxCollection = "index_outbox"
+	// outboxLocksCollection holds one document per note whose entry is
+	// being applied, keyed by note ID.
+	outboxLocksCollection = "index_outbox_locks"
+)
+
+// EnqueueIndexOp records a pending index operation for a note. Call it with
+// the context of the transaction that writes the note.
+func EnqueueIndexOp(ctx context.Context, noteID, userID, op string) (*models.OutboxEntry, error) {
+	now := time.Now()
+	entry := &models.OutboxEntry{
+		ID:            uuid.New().String(),
+		NoteID:        noteID,
+		UserID:        userID,
+		Op:            op,
+		NextAttemptAt: now,
+		LockedUntil:   now,
+		CreatedAt:     now,
+	}
+
+	collection, err := mongoCollection(outboxCollection)
+	if err != nil {
+		return nil, err
+	}
+	_, err = collection.InsertOne(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// EnqueueReindex records an upsert for each of the given notes that still
+// exists outside the trash, across all users, and returns how many were
+// queued. The entries are left for the poller.
+func EnqueueReindex(ctx context.Context, noteIDs []string) (int, error) {
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return 0, err
+	}
+	cursor, err := collection.Find(ctx,
+		bson.M{"_id": bson.M{"$in": noteIDs}, "deleted_at": nil},
+		options.Find().SetProjection(bson.M{"_id": 1, "user_id": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	now := time.Now()
+	var entries []models.OutboxEntry
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID     string `bson:"_id"`
+			UserID string `bson:"user_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return 0, err
+		}
+		entries = append(entries, models.OutboxEntry{
+			ID:            uuid.New().String(),
+			NoteID:        doc.ID,
+			UserID:        doc.UserID,
+			Op:            models.IndexOpUpsert,
+			NextAttemptAt: now,
+			LockedUntil:   now,
+			CreatedAt:     now,
+		})
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	outbox, err := mongoCollection(outboxCollection)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := outbox.InsertMany(ctx, entries); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// ClaimOutboxEntry locks the oldest due entry for lease and counts the
+// attempt. It returns nil when nothing is due.
+func ClaimOutboxEntry(ctx context.Context, lease time.Duration) (*models.OutboxEntry, error) {
+	now := time.Now()
+	filter := bson.M{
+		"dead":            false,
+		"next_attempt_at": bson.M{"$lte": now},
+		"locked_until":    bson.M{"$lte": now},
+	}
+	return claimOutboxEntry(ctx, filter, now, lease, options.FindOneAndUpdate().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+}
+
+// ClaimOutboxEntryByID locks a specific entry unless another worker already
+// holds it. It returns nil when the entry is locked or gone.
+func ClaimOutboxEntryByID(ctx context.Context, id string, lease time.Duration) (*models.OutboxEntry, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id":          id,
+		"dead":         false,
+		"locked_until": bson.M{"$lte": now},
+	}
+	return claimOutboxEntry(ctx, filter, now, lease, options.FindOneAndUpdate())
+}
+
+func claimOutboxEntry(ctx context.Context, filter bson.M, now time.Time, lease time.Duration, opts *options.FindOneAndUpdateOptionsBuilder) (*models.OutboxEntry, error) {
+	update := bson.M{
+		"$set": bson.M{"locked_until": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	var entry models.OutboxEntry
+	collection, err := mongoCollection(outboxCollection)
+	if err != nil {
+		return nil, err
+	}
+	err = collection.
+		FindOneAndUpdate(ctx, filter, update, opts.SetReturnDocument(options.After)).
+		Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// CompleteOutboxEntry removes an entry that has been applied.
+func CompleteOutboxEntry(ctx context.Context, id string) error {
+	collection, err := mongoCollection(outboxCollection)
+	if err != nil {
+		return err
+	}
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// RescheduleOutboxEntry releases a failed entry for another attempt at
+// next, or parks it for inspection when dead is set.
+func RescheduleOutboxEntry(ctx context.Context, id string, next time.Time, lastError string, dead bool) error {
+	collection, err := mongoCollection(outboxCollection)
+	if err != nil {
+		return err
+	}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"next_attempt_at": next,
+			"locked_until":    time.Time{},
+			"last_error":      lastError,
+			"dead":            dead,
+		},
+	})
+	return err
+}
+
+// ReleaseOutboxEntry hands a claimed entry back without counting the
+// attempt, to be picked up again at next.
+func ReleaseOutboxEntry(ctx context.Context, id string, next time.Time) error {
+	collection, err := mongoCollection(outboxCollection)
+	if err != nil {
+		return err
+	}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"next_attempt_at": next,
+			"locked_until":    time.Time{},
+		},
+		"$inc": bson.M{"attempts": -1},
+	})
+	return err
+}
+
+// LockOutboxNote takes the note's lock on behalf of an entry for lease, so
+// that two entries for the same note are never applied at once. It
+// reports false when another entry holds an unexpired lock.
+func LockOutboxNote(ctx context.Context, noteID, entryID string, lease time.Duration) (bool, error) {
+	now := time.Now()
+	collection, err := mongoCollection(outboxLocksCollection)
+	if err != nil {
+		return false, err
+	}
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"_id": noteID, "locked_until": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"entry_id": entryID, "locked_until": now.Add(lease)}},
+		options.UpdateOne().SetUpsert(true),
+	)
+	// The upsert collides with the live lock's document.
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnlockOutboxNote releases a lock taken by LockOutboxNote, unless it has
+// expired and another entry took it over since.
+func UnlockOutboxNote(ctx context.Context, noteID, entryID string) error {
+	collection, err := mongoCollection(outboxLocksCollection)
+	if err != nil {
+		return err
+	}
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": noteID, "entry_id": entryID})
+	return err
+}
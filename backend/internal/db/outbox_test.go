@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-llm/internal/models"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func fetchOutboxEntry(t *testing.T, ctx context.Context, id string) models.OutboxEntry {
+	t.Helper()
+	var entry models.OutboxEntry
+	if err := testCollection(t, outboxCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&entry); err != nil {
+		t.Fatalf("failed to load outbox entry %s: %v", id, err)
+	}
+	return entry
+}
+
+func TestOutboxRetry(t *testing.T) {
+	ctx := mongoTest(t)
+
+	entry, err := EnqueueIndexOp(ctx, uuid.New().String(), "u1", models.IndexOpUpsert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { CompleteOutboxEntry(context.Background(), entry.ID) })
+
+	claimed, err := ClaimOutboxEntryByID(ctx, entry.ID, time.Minute)
+	if err != nil || claimed == nil || claimed.Attempts != 1 {
+		t.Fatalf("first claim = %+v, %v; want attempt 1", claimed, err)
+	}
+	if again, err := ClaimOutboxEntryByID(ctx, entry.ID, time.Minute); err != nil || again != nil {
+		t.Fatalf("claimed a leased entry: %+v, %v", again, err)
+	}
+
+	next := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	if err := RescheduleOutboxEntry(ctx, entry.ID, next, "embedder down", false); err != nil {
+		t.Fatal(err)
+	}
+	got := fetchOutboxEntry(t, ctx, entry.ID)
+	if got.Attempts != 1 || got.LastError != "embedder down" || got.Dead || !got.NextAttemptAt.Equal(next) || !got.LockedUntil.IsZero() {
+		t.Fatalf("rescheduled entry = %+v", got)
+	}
+
+	// Handing an entry back doesn't use up an attempt.
+	if claimed, _ = ClaimOutboxEntryByID(ctx, entry.ID, time.Minute); claimed == nil || claimed.Attempts != 2 {
+		t.Fatalf("second claim = %+v", claimed)
+	}
+	if err := ReleaseOutboxEntry(ctx, entry.ID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := fetchOutboxEntry(t, ctx, entry.ID); got.Attempts != 1 || !got.LockedUntil.IsZero() {
+		t.Fatalf("released entry = %+v", got)
+	}
+
+	// Dead entries are parked for inspection and never claimed again.
+	if err := RescheduleOutboxEntry(ctx, entry.ID, time.Now(), "gave up", true); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, err := ClaimOutboxEntryByID(ctx, entry.ID, time.Minute); err != nil || claimed != nil {
+		t.Fatalf("claimed a dead entry: %+v, %v", claimed, err)
+	}
+}
+
+func TestLockOutboxNote(t *testing.T) {
+	ctx := mongoTest(t)
+	noteID := uuid.New().String()
+	t.Cleanup(func() {
+		testCollection(t, outboxLocksCollection).DeleteOne(context.Background(), bson.M{"_id": noteID})
+	})
+
+	lock := func(entryID string, lease time.Duration) bool {
+		t.Helper()
+		locked, err := LockOutboxNote(ctx, noteID, entryID, lease)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return locked
+	}
+
+	if !lock("e1", time.Minute) {
+		t.Fatal("free note not locked")
+	}
+	if lock("e2", time.Minute) {
+		t.Fatal("second entry locked a held note")
+	}
+	// Only the holder can unlock.
+	if err := UnlockOutboxNote(ctx, noteID, "e2"); err != nil {
+		t.Fatal(err)
+	}
+	if lock("e2", time.Minute) {
+		t.Fatal("unlock by another entry released the lock")
+	}
+	if err := UnlockOutboxNote(ctx, noteID, "e1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// An expired lock is taken over, and its old holder can't release
+	// the new one.
+	if !lock("e2", -time.Second) {
+		t.Fatal("released note not locked")
+	}
+	if !lock("e3", time.Minute) {
+		t.Fatal("expired lock not taken over")
+	}
+	if err := UnlockOutboxNote(ctx, noteID, "e2"); err != nil {
+		t.Fatal(err)
+	}
+	if lock("e4", time.Minute) {
+		t.Fatal("stale holder released the new lock")
+	}
+}
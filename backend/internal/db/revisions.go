@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"note-llm/internal/models"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const revisionsCollection = "note_revisions"
+
+// SaveRevision stores the given state of a note as its next revision. Call
+// it with the context of the transaction that changes the note, before
+// the change is written.
+func SaveRevision(ctx context.Context, note models.Note) (*models.NoteRevision, error) {
+	collection, err := mongoCollection(revisionsCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	var last models.NoteRevision
+	err = collection.FindOne(ctx,
+		bson.M{"note_id": note.ID, "user_id": note.UserID},
+		options.FindOne().SetSort(bson.D{{Key: "number", Value: -1}}).SetProjection(bson.M{"number": 1}),
+	).Decode(&last)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	rev := &models.NoteRevision{
+		ID:         uuid.New().String(),
+		NoteID:     note.ID,
+		UserID:     note.UserID,
+		Number:     last.Number + 1,
+		Title:      note.Title,
+		Content:    note.Content,
+		NotebookID: note.NotebookID,
+		Tags:       note.Tags,
+		ModifiedAt: note.ModifiedAt,
+		CreatedAt:  time.Now(),
+	}
+	if _, err := collection.InsertOne(ctx, rev); err != nil {
+		return nil, err
+	}
+	return rev, nil
+}
+
+// ListRevisions returns a note's revisions without their content, newest
+// first.
+func ListRevisions(ctx context.Context, noteID, userID string) ([]models.NoteRevision, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "number", Value: -1}}).
+		SetProjection(bson.M{"content": 0})
+
+	collection, err := mongoCollection(revisionsCollection)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Find(ctx, bson.M{"note_id": noteID, "user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []models.NoteRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// FetchRevision returns nil without an error when the revision does not
+// exist.
+func FetchRevision(ctx context.Context, noteID, userID string, number int) (*models.NoteRevision, error) {
+	var rev models.NoteRevision
+	collection, err := mongoCollection(revisionsCollection)
+	if err != nil {
+		return nil, err
+	}
+	err = collection.
+		FindOne(ctx, bson.M{"note_id": noteID, "user_id": userID, "number": number}).
+		Decode(&rev)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// DeleteRevisions removes every revision of the given notes.
+func DeleteRevisions(ctx context.Context, userID string, noteIDs ...string) error {
+	collection, err := mongoCollection(revisionsCollection)
+	if err != nil {
+		return err
+	}
+	_, err = collection.DeleteMany(ctx, bson.M{
+		"user_id": userID,
+		"note_id": bson.M{"$in": noteIDs},
+	})
+	return err
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// errCodeIllegalOperation is returned by standalone servers, which don't
+// support multi-document transactions.
+const errCodeIllegalOperation = 20
+
+var warnNoTransactions sync.Once
+
+// WithTransaction runs fn inside a multi-document transaction. fn must use
+// the context it is given for every operation that belongs to the
+// transaction. On a standalone server (no replica set) fn runs without a
+// transaction instead, so local development keeps working.
+func WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	database, err := GetMongoDatabase()
+	if err != nil {
+		return err
+	}
+
+	session, err := database.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		return nil, fn(txCtx)
+	})
+
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && serverErr.HasErrorCode(errCodeIllegalOperation) {
+		warnNoTransactions.Do(func() {
+			fmt.Println("MongoDB does not support transactions (standalone server?); writing without them")
+		})
+		return fn(ctx)
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"note-llm/internal/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// inTrash matches notes that have been moved to the trash.
+var inTrash = bson.M{"$ne": nil}
+
+// TrashNote moves a note at version to the trash and reports whether it
+// was found outside of it at that version.
+func TrashNote(ctx context.Context, noteID, userID string, version int64) (bool, error) {
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return false, err
+	}
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": noteID, "user_id": userID, "deleted_at": nil, "version": versionMatch(version)},
+		bson.M{"$set": bson.M{"deleted_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// FetchTrashedNote loads a note of a user from the trash. It returns nil
+// without an error when the note is not in the trash.
+func FetchTrashedNote(ctx context.Context, noteID, userID string) (*models.Note, error) {
+	var note models.Note
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return nil, err
+	}
+	err = collection.FindOne(ctx, bson.M{"_id": noteID, "user_id": userID, "deleted_at": inTrash}).Decode(&note)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// UntrashNote takes a note at version out of the trash, marking it for
+// re-indexing, and reports whether it was in the trash at that version.
+func UntrashNote(ctx context.Context, noteID, userID string, version int64) (bool, error) {
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return false, err
+	}
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": noteID, "user_id": userID, "deleted_at": inTrash, "version": versionMatch(version)},
+		bson.M{
+			"$unset": bson.M{"deleted_at": ""},
+			"$set":   bson.M{"index_status": models.IndexStatusPending, "index_error": ""},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// ListTrash returns a user's trashed notes, most recently deleted first.
+func ListTrash(ctx context.Context, userID string) ([]models.Note, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "deleted_at", Value: -1}}).
+		SetProjection(bson.M{"embeddings": 0})
+
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID, "deleted_at": inTrash}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notes := []models.Note{}
+	if err := cursor.All(ctx, &notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// PurgeTrashedNote permanently deletes a trashed note at version with its
+// revisions and reports whether it was in the trash at that version. Call
+// it inside a transaction.
+func PurgeTrashedNote(ctx context.Context, noteID, userID string, version int64) (bool, error) {
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return false, err
+	}
+	result, err := collection.DeleteOne(ctx,
+		bson.M{"_id": noteID, "user_id": userID, "deleted_at": inTrash, "version": versionMatch(version)},
+	)
+	if err != nil || result.DeletedCount == 0 {
+		return false, err
+	}
+	return true, DeleteRevisions(ctx, userID, noteID)
+}
+
+// ExpiredTrash returns up to limit notes, of any user, that were trashed
+// before cutoff.
+func ExpiredTrash(ctx context.Context, cutoff time.Time, limit int64) ([]models.Note, error) {
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1, "user_id": 1, "version": 1}).
+		SetLimit(limit)
+
+	collection, err := mongoCollection("notes")
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Find(ctx, bson.M{"deleted_at": bson.M{"$lt": cutoff}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var notes []models.Note
+	if err := cursor.All(ctx, &notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"note-llm/internal/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// FetchUserByEmail returns nil without an error when no user has the email.
+func FetchUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	collection, err := mongoCollection("users")
+	if err != nil {
+		return nil, err
+	}
+	err = collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func InsertUser(ctx context.Context, user models.User) error {
+	collection, err := mongoCollection("users")
+	if err != nil {
+		return err
+	}
+	_, err = collection.InsertOne(ctx, user)
+	return err
+}
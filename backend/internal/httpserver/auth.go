@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"time"
 
-	"note-llm/internal/db"
 	"note-llm/internal/models"
 
 	"github.com/go-chi/chi/v5"
@@ -16,7 +15,6 @@ import (
 	"github.com/markbates/goth/gothic"
 	"github.com/markbates/goth/providers/google"
 	"github.com/spf13/viper"
-	"go.mongodb.org/mongo-driver/bson"
 )
 
 func generateJWT(email string) (string, error) {
@@ -39,15 +37,14 @@ func SetupAuthProviders() {
 	)
 }
 
-func createUserIfNotExists(ctx context.Context, user goth.User) int {
-
-	collection := db.GetMongoDatabase().Collection("users")
-	var existing models.User
-	err := collection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&existing)
-
+func (s *Server) createUserIfNotExists(ctx context.Context, user goth.User) int {
+	existing, err := s.Users.FindByEmail(ctx, user.Email)
 	if err != nil {
-
 		fmt.Println(err.Error())
+		return http.StatusInternalServerError
+	}
+
+	if existing == nil {
 		// Not found → create new user
 		newUser := models.User{
 			ID:        uuid.New().String(),
@@ -57,8 +54,7 @@ func createUserIfNotExists(ctx context.Context, user goth.User) int {
 			CreatedAt: time.Now(),
 		}
 
-		_, err := collection.InsertOne(ctx, newUser)
-		if err != nil {
+		if err := s.Users.Create(ctx, newUser); err != nil {
 			fmt.Println(err.Error())
 			return http.StatusInternalServerError
 		}
@@ -67,7 +63,7 @@ func createUserIfNotExists(ctx context.Context, user goth.User) int {
 	return http.StatusOK
 }
 
-func Provider(w http.ResponseWriter, r *http.Request) {
+func (s *Server) Provider(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 50*time.Second)
 	defer cancel()
 
@@ -76,7 +72,7 @@ func Provider(w http.ResponseWriter, r *http.Request) {
 	q.Add("provider", provider)
 	r.URL.RawQuery = q.Encode()
 	if user, err := gothic.CompleteUserAuth(w, r); err == nil {
-		status := createUserIfNotExists(ctx, user)
+		status := s.createUserIfNotExists(ctx, user)
 		if status != http.StatusOK {
 			http.Error(w, "Failed to save user", status)
 			return
@@ -86,7 +82,7 @@ func Provider(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func Callback(w http.ResponseWriter, r *http.Request) {
+func (s *Server) Callback(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
@@ -102,7 +98,7 @@ func Callback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Typically you'd check if user exists in DB here
-	status := createUserIfNotExists(ctx, user)
+	status := s.createUserIfNotExists(ctx, user)
 	if status != http.StatusOK {
 		http.Error(w, "Failed to save user", status)
 		return
@@ -0,0 +1,220 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"note-llm/internal/llm"
+	"note-llm/internal/models"
+	"note-llm/internal/rag"
+	"note-llm/internal/search"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// conversationTitleLength caps titles derived from the first question.
+const conversationTitleLength = 80
+
+func (s *Server) CreateConversationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.CreateConversationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	userId := r.Context().Value(UserIDKey).(string)
+
+	now := time.Now()
+	conv := models.Conversation{
+		ID:        uuid.New().String(),
+		UserID:    userId,
+		Title:     strings.TrimSpace(req.Title),
+		Messages:  []models.ConversationMessage{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.Conversations.Create(ctx, conv); err != nil {
+		http.Error(w, "Failed to create conversation", errorStatus(err))
+		fmt.Printf("Insert conversation error: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(conv)
+}
+
+func (s *Server) GetAllConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userId := r.Context().Value(UserIDKey).(string)
+
+	conversations, err := s.Conversations.List(ctx, userId)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find conversations error: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conversations)
+}
+
+func (s *Server) GetConversationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userId := r.Context().Value(UserIDKey).(string)
+
+	conv, err := s.Conversations.Get(ctx, chi.URLParam(r, "id"), userId)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find conversation error: %v\n", err)
+		return
+	}
+	if conv == nil {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conv)
+}
+
+// ContinueConversationHandler asks a question within a conversation. Prior
+// turns are passed to the model and used to rewrite follow-ups into
+// standalone search queries; the new turn is then appended.
+func (s *Server) ContinueConversationHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		models.ConversationMessageRequest
+		Rerank *bool `json:"rerank"`
+		search.Options
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Question == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if err := req.Options.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	model, err := llm.ResolveChatModel(req.Model)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(string)
+	ctx := r.Context()
+	convID := chi.URLParam(r, "id")
+
+	conv, err := s.Conversations.Get(ctx, convID, userID)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find conversation error: %v\n", err)
+		return
+	}
+	if conv == nil {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return
+	}
+
+	asked := time.Now()
+	answer, err := rag.AnswerFromUserNotes(ctx, s.searchStores(), rag.Request{
+		UserID:   userID,
+		Question: req.Question,
+		Model:    model,
+		History:  conversationHistory(conv.Messages, s.HistoryTurns),
+		Search:   req.Options,
+		Rerank:   req.Rerank,
+	})
+	if err != nil {
+		fmt.Print(err.Error())
+		http.Error(w, "Failed to generate answer", errorStatus(err))
+		return
+	}
+
+	found, err := s.Conversations.AppendMessages(ctx, convID, userID, conversationTitle(req.Question),
+		models.ConversationMessage{
+			Role:      llm.RoleUser,
+			Content:   req.Question,
+			CreatedAt: asked,
+		},
+		models.ConversationMessage{
+			Role:      llm.RoleAssistant,
+			Content:   answer.Answer,
+			Query:     answer.Query,
+			Model:     answer.Model,
+			NoteIDs:   answer.NoteIDs,
+			Citations: answer.Citations,
+			CreatedAt: time.Now(),
+		},
+	)
+	if err != nil || !found {
+		// The answer is still worth returning; only the history is lost.
+		fmt.Printf("Append conversation %s error: %v (found: %v)\n", convID, err, found)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		ConversationID string `json:"conversation_id"`
+		*rag.Answer
+	}{convID, answer})
+}
+
+func (s *Server) DeleteConversationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userId := r.Context().Value(UserIDKey).(string)
+
+	deleted, err := s.Conversations.Delete(ctx, chi.URLParam(r, "id"), userId)
+	if err != nil {
+		http.Error(w, "Failed to delete conversation", errorStatus(err))
+		return
+	}
+	if !deleted {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// conversationHistory converts the most recent turns question/answer pairs
+// into chat messages.
+func conversationHistory(messages []models.ConversationMessage, turns int) []llm.ChatMessage {
+	limit := turns * 2
+	if len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+
+	history := make([]llm.ChatMessage, 0, len(messages))
+	for _, msg := range messages {
+		history = append(history, llm.ChatMessage{Role: msg.Role, Content: msg.Content})
+	}
+	return history
+}
+
+// conversationTitle derives a title from the first question.
+func conversationTitle(question string) string {
+	title := strings.Join(strings.Fields(question), " ")
+	if utf8.RuneCountInString(title) <= conversationTitleLength {
+		return title
+	}
+	return string([]rune(title)[:conversationTitleLength-1]) + "…"
+}
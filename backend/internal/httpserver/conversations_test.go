@@ -0,0 +1,50 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"note-llm/internal/models"
+)
+
+func TestConversationHistoryKeepsRecentTurns(t *testing.T) {
+	var messages []models.ConversationMessage
+	for i := 1; i <= 4; i++ {
+		messages = append(messages,
+			models.ConversationMessage{Role: "user", Content: fmt.Sprintf("q%d", i)},
+			models.ConversationMessage{Role: "assistant", Content: fmt.Sprintf("a%d", i)},
+		)
+	}
+
+	history := conversationHistory(messages, 2)
+	if len(history) != 4 || history[0].Content != "q3" || history[3].Content != "a4" {
+		t.Fatalf("history = %+v, want the last two turns", history)
+	}
+	if history := conversationHistory(messages, 10); len(history) != len(messages) {
+		t.Errorf("got %d messages, want all %d", len(history), len(messages))
+	}
+}
+
+func TestConversations(t *testing.T) {
+	c := newTestClient(t)
+	c.createNote("Sourdough", "Feed the sourdough starter with rye flour every morning.")
+
+	var conv models.Conversation
+	c.expect(c.do("POST", "/conversations/", nil, ""), http.StatusCreated, &conv)
+	c.expect(c.do("POST", "/conversations/"+conv.ID+"/messages", map[string]any{"question": "How do I feed the starter?"}, ""), http.StatusOK, nil)
+
+	c.expect(c.do("GET", "/conversations/"+conv.ID, nil, ""), http.StatusOK, &conv)
+	if len(conv.Messages) != 2 || conv.Title != "How do I feed the starter?" {
+		t.Fatalf("conversation = %+v", conv)
+	}
+
+	var list []models.Conversation
+	c.expect(c.do("GET", "/conversations/", nil, ""), http.StatusOK, &list)
+	if len(list) != 1 || len(list[0].Messages) != 0 {
+		t.Errorf("list = %+v", list)
+	}
+
+	c.expect(c.do("DELETE", "/conversations/"+conv.ID, nil, ""), http.StatusNoContent, nil)
+	c.expect(c.do("GET", "/conversations/"+conv.ID, nil, ""), http.StatusNotFound, nil)
+}
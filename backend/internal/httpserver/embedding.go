@@ -0,0 +1,41 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"note-llm/internal/indexing"
+	"note-llm/internal/models"
+)
+
+// includesEmbedding reports whether the request opted into note vectors
+// with ?include=embedding. They are large and only useful for debugging,
+// so responses leave them out by default.
+func includesEmbedding(r *http.Request) bool {
+	for _, part := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(part) == "embedding" {
+			return true
+		}
+	}
+	return false
+}
+
+// attachEmbeddings fills in each note's embedding as the normalized mean of
+// its chunk vectors in the vector store, the store of record for vectors.
+// Notes that aren't indexed yet are left without one.
+func (s *Server) attachEmbeddings(ctx context.Context, userID string, notes []models.NoteResponse) error {
+	ids := make([]string, len(notes))
+	for i, note := range notes {
+		ids[i] = note.ID
+	}
+
+	vectors, err := s.Vectors.NoteChunkVectors(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+	for i := range notes {
+		notes[i].Embedding = indexing.MeanVector(vectors[notes[i].ID])
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"note-llm/internal/models"
+)
+
+// noteETag is the strong ETag of a note version.
+func noteETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// matchesETag reports whether an If-Match header value lists etag. Weak
+// tags never match.
+func matchesETag(ifMatch, etag string) bool {
+	if ifMatch == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(tag) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// noteLookup loads a user's note, or returns nil when it does not exist.
+// Notes.Get finds notes outside the trash and Notes.GetTrashed those in it.
+type noteLookup func(ctx context.Context, noteID, userID string) (*models.Note, error)
+
+// checkIfMatch loads the note a request wants to change with lookup and
+// checks it against the request's If-Match header. It answers 428 when the
+// header is missing, 404 when the note is, and 412 with the current note
+// when the client's copy is stale, returning false in each case.
+func (s *Server) checkIfMatch(ctx context.Context, w http.ResponseWriter, r *http.Request, lookup noteLookup, noteID, userId string) (*models.Note, bool) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" {
+		http.Error(w, "Missing If-Match header", http.StatusPreconditionRequired)
+		return nil, false
+	}
+
+	note, err := lookup(ctx, noteID, userId)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find note error: %v\n", err)
+		return nil, false
+	}
+	if note == nil {
+		http.Error(w, "Note not found or unauthorized", http.StatusNotFound)
+		return nil, false
+	}
+	if !matchesETag(ifMatch, noteETag(note.Version)) {
+		writeConflictingNote(w, note)
+		return nil, false
+	}
+	return note, true
+}
+
+// writeVersionConflict answers 412 with the server's current copy of the
+// note, loaded with lookup, so the client can merge or retry.
+func (s *Server) writeVersionConflict(ctx context.Context, w http.ResponseWriter, lookup noteLookup, noteID, userId string) {
+	note, err := lookup(ctx, noteID, userId)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find note error: %v\n", err)
+		return
+	}
+	if note == nil {
+		http.Error(w, "Note not found or unauthorized", http.StatusNotFound)
+		return
+	}
+	writeConflictingNote(w, note)
+}
+
+func writeConflictingNote(w http.ResponseWriter, note *models.Note) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", noteETag(note.Version))
+	w.WriteHeader(http.StatusPreconditionFailed)
+	json.NewEncoder(w).Encode(models.NewNoteResponse(*note))
+}
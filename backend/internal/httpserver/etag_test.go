@@ -0,0 +1,60 @@
+package httpserver
+
+import (
+	"net/http"
+	"testing"
+
+	"note-llm/internal/models"
+)
+
+func TestUpdateChecksETag(t *testing.T) {
+	c := newTestClient(t)
+	note := c.createNote("Plan", "Draft")
+	change := models.UpdateNoteRequest{Title: "Plan", Content: "Final"}
+
+	c.expect(c.do("PUT", "/notes/"+note.ID, change, ""), http.StatusPreconditionRequired, nil)
+	c.expect(c.do("PUT", "/notes/missing", change, `"1"`), http.StatusNotFound, nil)
+	c.expect(c.do("PUT", "/notes/"+note.ID, change, `"1"`), http.StatusOK, nil)
+
+	// A second writer still holding version 1 gets the current note back.
+	rec := c.do("PUT", "/notes/"+note.ID, models.UpdateNoteRequest{Title: "Plan", Content: "Stale"}, `"1"`)
+	var current models.NoteResponse
+	c.expect(rec, http.StatusPreconditionFailed, &current)
+	if current.Content != "Final" || rec.Header().Get("ETag") != `"2"` {
+		t.Errorf("conflict body = %+v, ETag %s", current, rec.Header().Get("ETag"))
+	}
+
+	c.expect(c.do("DELETE", "/notes/"+note.ID, nil, `"1"`), http.StatusPreconditionFailed, nil)
+	c.expect(c.do("PUT", "/notes/"+note.ID, change, `"3", "2"`), http.StatusOK, nil)
+	c.expect(c.do("DELETE", "/notes/"+note.ID, nil, "*"), http.StatusNoContent, nil)
+}
+
+func TestRestoreAndPurgeCheckETag(t *testing.T) {
+	c := newTestClient(t)
+	note := c.createNote("Plan", "Draft")
+	c.expect(c.do("PUT", "/notes/"+note.ID, models.UpdateNoteRequest{Title: "Plan", Content: "Final"}, `"1"`), http.StatusOK, nil)
+
+	restore := "/notes/" + note.ID + "/revisions/1/restore"
+	c.expect(c.do("POST", restore, nil, ""), http.StatusPreconditionRequired, nil)
+	c.expect(c.do("POST", restore, nil, `"1"`), http.StatusPreconditionFailed, nil)
+	rec := c.do("POST", restore, nil, `"2"`)
+	var restored models.NoteResponse
+	c.expect(rec, http.StatusOK, &restored)
+	if restored.Content != "Draft" || rec.Header().Get("ETag") != `"3"` {
+		t.Errorf("restored = %+v, ETag %s", restored, rec.Header().Get("ETag"))
+	}
+
+	c.expect(c.do("DELETE", "/notes/"+note.ID, nil, `"3"`), http.StatusNoContent, nil)
+	for _, req := range []struct{ method, path string }{
+		{"POST", "/trash/" + note.ID + "/restore"},
+		{"DELETE", "/trash/" + note.ID},
+	} {
+		c.expect(c.do(req.method, req.path, nil, ""), http.StatusPreconditionRequired, nil)
+		rec := c.do(req.method, req.path, nil, `"2"`)
+		c.expect(rec, http.StatusPreconditionFailed, nil)
+		if got := rec.Header().Get("ETag"); got != `"3"` {
+			t.Errorf("%s %s conflict ETag = %s, want \"3\"", req.method, req.path, got)
+		}
+	}
+	c.expect(c.do("DELETE", "/trash/"+note.ID, nil, `"3"`), http.StatusNoContent, nil)
+}
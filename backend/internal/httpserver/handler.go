@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"note-llm/internal/db"
@@ -13,14 +15,23 @@ import (
 	"note-llm/internal/models"
 	"note-llm/internal/qdrant"
 	"note-llm/internal/rag"
+	"note-llm/internal/repository"
+	"note-llm/internal/search"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func CreateNoteHandler(w http.ResponseWriter, r *http.Request) {
+// errorStatus is the status for a request that failed with err: 503 once
+// the MongoDB or Qdrant client has been closed for shutdown, 500 otherwise.
+func errorStatus(err error) int {
+	if errors.Is(err, db.ErrClosed) || errors.Is(err, qdrant.ErrClosed) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func (s *Server) CreateNoteHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
@@ -41,41 +52,74 @@ func CreateNoteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stringToEmbed := fmt.Sprintf("%s\n\n%s", req.Title, req.Content)
-	embeddings, err := llm.GetEmbeddings([]string{stringToEmbed})
-	if err != nil {
-		http.Error(w, "Failed to generate embedding", http.StatusInternalServerError)
-		fmt.Printf("Embedding error: %v\n", err)
+	if !s.checkNotebook(ctx, w, req.NotebookID, userId) {
 		return
 	}
 
 	note := models.Note{
-		ID:         uuid.New().String(),
-		Title:      req.Title,
-		Content:    req.Content,
-		Embeddings: embeddings[0],
-		UserID:     userId,
-		CreatedAt:  time.Now(),
-		ModifiedAt: time.Now(),
+		ID:          uuid.New().String(),
+		Title:       req.Title,
+		Content:     req.Content,
+		UserID:      userId,
+		NotebookID:  req.NotebookID,
+		Tags:        models.NormalizeTags(req.Tags),
+		CreatedAt:   time.Now(),
+		ModifiedAt:  time.Now(),
+		IndexStatus: models.IndexStatusPending,
+		Version:     1,
 	}
 
-	collection := db.GetMongoDatabase().Collection("notes")
-	_, err = collection.InsertOne(ctx, note)
-	if err != nil {
-		http.Error(w, "Failed to save note", http.StatusInternalServerError)
+	if err := s.Notes.Create(ctx, note); err != nil {
+		http.Error(w, "Failed to save note", errorStatus(err))
 		fmt.Printf("Insert error: %v\n", err)
 		return
 	}
-	err = qdrant.InsertNoteEmbedding(note.ID, userId, embeddings[0])
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", noteETag(note.Version))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.NewNoteResponse(note))
+}
+
+func (s *Server) GetNoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	noteID := chi.URLParam(r, "id")
+	if noteID == "" {
+		http.Error(w, "Missing note ID", http.StatusBadRequest)
+		return
+	}
+
+	userId := r.Context().Value(UserIDKey).(string)
+
+	note, err := s.Notes.Get(ctx, noteID, userId)
 	if err != nil {
-		fmt.Printf("Qdrant insert error: %v\n", err)
+		fmt.Println(err.Error())
+		http.Error(w, "Database error", errorStatus(err))
+		return
+	}
+	if note == nil {
+		http.Error(w, "Note not found", http.StatusNotFound)
+		return
 	}
+
+	resp := []models.NoteResponse{models.NewNoteResponse(*note)}
+	if includesEmbedding(r) {
+		if err := s.attachEmbeddings(ctx, userId, resp); err != nil {
+			fmt.Printf("Fetch embedding error: %v\n", err)
+			http.Error(w, "Failed to fetch embedding", errorStatus(err))
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", noteETag(note.Version))
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(note)
+	json.NewEncoder(w).Encode(resp[0])
 }
 
-func GetNoteHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetNoteIndexStatusHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
@@ -85,53 +129,110 @@ func GetNoteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var note models.Note
-	collection := db.GetMongoDatabase().Collection("notes")
-
 	userId := r.Context().Value(UserIDKey).(string)
 
-	err := collection.FindOne(ctx, bson.M{"_id": noteID, "user_id": userId}).Decode(&note)
+	note, err := s.Notes.Get(ctx, noteID, userId)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			http.Error(w, "Note not found", http.StatusNotFound)
-		} else {
-			fmt.Println(err.Error())
-			http.Error(w, "Database error", http.StatusInternalServerError)
-		}
+		fmt.Println(err.Error())
+		http.Error(w, "Database error", errorStatus(err))
 		return
 	}
+	if note == nil {
+		http.Error(w, "Note not found", http.StatusNotFound)
+		return
+	}
+
+	// Notes created before index tracking existed were indexed inline.
+	status := note.IndexStatus
+	if status == "" {
+		status = models.IndexStatusIndexed
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(note)
+	json.NewEncoder(w).Encode(models.NoteIndexStatus{
+		NoteID:      note.ID,
+		IndexStatus: status,
+		IndexError:  note.IndexError,
+		IndexedAt:   note.IndexedAt,
+		ModifiedAt:  note.ModifiedAt,
+	})
 }
 
-func GetAllNotesHandler(w http.ResponseWriter, r *http.Request) {
+// GetAllNotesHandler returns a page of the user's notes. Query parameters:
+// sort (created, modified or title; default modified), order (asc or desc;
+// default desc, asc for title), limit (1-200, default 50), cursor (the
+// next_cursor of the previous page), notebook_id and tags (a,b; notes must
+// have all of them), and include=embedding.
+func (s *Server) GetAllNotesHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	userId := r.Context().Value(UserIDKey).(string)
+	params := r.URL.Query()
 
-	collection := db.GetMongoDatabase().Collection("notes")
-	cursor, err := collection.Find(ctx, bson.M{"user_id": userId})
+	q := models.NoteListQuery{
+		Sort:   models.NoteSortModified,
+		Limit:  50,
+		Cursor: params.Get("cursor"),
+	}
+	if raw := params.Get("sort"); raw != "" {
+		q.Sort = raw
+	}
+	switch q.Sort {
+	case models.NoteSortCreated, models.NoteSortModified:
+		q.Desc = true
+	case models.NoteSortTitle:
+	default:
+		http.Error(w, "sort must be created, modified or title", http.StatusBadRequest)
+		return
+	}
+	switch params.Get("order") {
+	case "":
+	case "asc":
+		q.Desc = false
+	case "desc":
+		q.Desc = true
+	default:
+		http.Error(w, "order must be asc or desc", http.StatusBadRequest)
+		return
+	}
+	if raw := params.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 200 {
+			http.Error(w, "limit must be between 1 and 200", http.StatusBadRequest)
+			return
+		}
+		q.Limit = n
+	}
+	q.Filter.NotebookID = params.Get("notebook_id")
+	if raw := params.Get("tags"); raw != "" {
+		q.Filter.Tags = strings.Split(raw, ",")
+	}
+
+	page, err := s.Notes.List(ctx, userId, q)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		http.Error(w, "Database error", errorStatus(err))
 		fmt.Printf("Find error: %v\n", err)
 		return
 	}
-	defer cursor.Close(ctx)
 
-	var notes []models.Note
-	if err := cursor.All(ctx, &notes); err != nil {
-		http.Error(w, "Failed to parse notes", http.StatusInternalServerError)
-		return
+	if includesEmbedding(r) {
+		if err := s.attachEmbeddings(ctx, userId, page.Notes); err != nil {
+			fmt.Printf("Fetch embedding error: %v\n", err)
+			http.Error(w, "Failed to fetch embeddings", errorStatus(err))
+			return
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(notes)
+	json.NewEncoder(w).Encode(page)
 }
 
-func UpdateNoteHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UpdateNoteHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
@@ -149,39 +250,35 @@ func UpdateNoteHandler(w http.ResponseWriter, r *http.Request) {
 
 	userId := r.Context().Value(UserIDKey).(string)
 
-	collection := db.GetMongoDatabase().Collection("notes")
-	filter := bson.M{"_id": noteID, "user_id": userId}
-	update := bson.M{
-		"$set": bson.M{
-			"title":       req.Title,
-			"content":     req.Content,
-			"modified_at": time.Now(),
-		},
+	current, ok := s.checkIfMatch(ctx, w, r, s.Notes.Get, noteID, userId)
+	if !ok {
+		return
 	}
-
-	result, err := collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		http.Error(w, "Failed to update note", http.StatusInternalServerError)
+	if req.NotebookID != nil && *req.NotebookID != "" && !s.checkNotebook(ctx, w, *req.NotebookID, userId) {
 		return
 	}
 
-	if result.MatchedCount == 0 {
+	updatedNote, err := s.Notes.Update(ctx, noteID, userId, current.Version, req)
+	if errors.Is(err, repository.ErrNotFound) {
 		http.Error(w, "Note not found or unauthorized", http.StatusNotFound)
 		return
 	}
-
-	var updatedNote models.Note
-	err = collection.FindOne(ctx, filter).Decode(&updatedNote)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		s.writeVersionConflict(ctx, w, s.Notes.Get, noteID, userId)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to retrieve updated note", http.StatusInternalServerError)
+		http.Error(w, "Failed to update note", errorStatus(err))
+		fmt.Printf("Update error: %v\n", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedNote)
+	w.Header().Set("ETag", noteETag(updatedNote.Version))
+	json.NewEncoder(w).Encode(models.NewNoteResponse(*updatedNote))
 }
 
-func DeleteNoteHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) DeleteNoteHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
@@ -193,40 +290,129 @@ func DeleteNoteHandler(w http.ResponseWriter, r *http.Request) {
 
 	userId := r.Context().Value(UserIDKey).(string)
 
-	collection := db.GetMongoDatabase().Collection("notes")
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": noteID, "user_id": userId})
-	if err != nil {
-		http.Error(w, "Failed to delete note", http.StatusInternalServerError)
+	current, ok := s.checkIfMatch(ctx, w, r, s.Notes.Get, noteID, userId)
+	if !ok {
 		return
 	}
 
-	if result.DeletedCount == 0 {
+	err := s.Notes.Trash(ctx, noteID, userId, current.Version)
+	if errors.Is(err, repository.ErrNotFound) {
 		http.Error(w, "Note not found or unauthorized", http.StatusNotFound)
 		return
 	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		s.writeVersionConflict(ctx, w, s.Notes.Get, noteID, userId)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete note", errorStatus(err))
+		return
+	}
 
 	w.WriteHeader(http.StatusNoContent) // 204 No Content
 }
 
-func AskQuestionHandler(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Question string `json:"question"`
+func (s *Server) SearchNotesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "Missing query", http.StatusBadRequest)
+		return
 	}
 
+	opts := search.Options{Limit: 10}
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 50 {
+			http.Error(w, "limit must be between 1 and 50", http.StatusBadRequest)
+			return
+		}
+		opts.Limit = n
+	}
+	if raw := r.URL.Query().Get("min_score"); raw != "" {
+		f, err := strconv.ParseFloat(raw, 32)
+		if err != nil {
+			http.Error(w, "Invalid min_score", http.StatusBadRequest)
+			return
+		}
+		minScore := float32(f)
+		opts.MinScore = &minScore
+	}
+	if raw := r.URL.Query().Get("mmr"); raw != "" {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "Invalid mmr", http.StatusBadRequest)
+			return
+		}
+		opts.MMR = &b
+	}
+	if raw := r.URL.Query().Get("mmr_lambda"); raw != "" {
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			http.Error(w, "Invalid mmr_lambda", http.StatusBadRequest)
+			return
+		}
+		opts.MMRLambda = &f
+	}
+	if err := opts.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userId := r.Context().Value(UserIDKey).(string)
+
+	results, err := search.HybridSearch(ctx, s.searchStores(), userId, query, opts)
+	if err != nil {
+		fmt.Printf("Search error: %v\n", err)
+		http.Error(w, "Search failed", errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"query": query, "results": results})
+}
+
+// askRequest is the body of the /notes/ask endpoints. The retrieval options
+// (limit, min_score, mmr, mmr_lambda) and filters (notebook_id, tags,
+// note_ids, created_after/before, modified_after/before) sit next to the
+// question; rerank overrides whether the reranking stage runs.
+type askRequest struct {
+	Question string `json:"question"`
+	Model    string `json:"model"`
+	Rerank   *bool  `json:"rerank"`
+	search.Options
+}
+
+func (s *Server) AskQuestionHandler(w http.ResponseWriter, r *http.Request) {
+	var req askRequest
+
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Question == "" {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
+	if err := req.Options.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	model, err := llm.ResolveChatModel(req.Model)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	userID := r.Context().Value(UserIDKey).(string)
 	ctx := r.Context()
 
-	answer, err := rag.AnswerFromUserNotes(ctx, userID, req.Question)
+	answer, err := rag.AnswerFromUserNotes(ctx, s.searchStores(), rag.Request{UserID: userID, Question: req.Question, Model: model, Search: req.Options, Rerank: req.Rerank})
 	if err != nil {
 		fmt.Print(err.Error())
-		http.Error(w, "Failed to generate answer", http.StatusInternalServerError)
+		http.Error(w, "Failed to generate answer", errorStatus(err))
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{"answer": answer})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(answer)
 }
@@ -0,0 +1,70 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// dependencyStatus is the outcome of one readiness check. Why a check
+// failed is only logged: the endpoint is public and errors can name hosts.
+type dependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+type readiness struct {
+	Status string                      `json:"status"`
+	Checks map[string]dependencyStatus `json:"checks"`
+}
+
+// HealthzHandler reports that the process is up and serving. It checks no
+// dependencies, so a restart can't be triggered by an outage elsewhere.
+func (s *Server) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// ReadyzHandler runs every health check in parallel, each limited to
+// READINESS_TIMEOUT (default 2s), and answers 503 unless all pass.
+func (s *Server) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	result := readiness{Status: "ok", Checks: make(map[string]dependencyStatus, len(s.Checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range s.Checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(r.Context(), s.ReadinessTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Probe(ctx)
+			status := dependencyStatus{Status: "ok", LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				status.Status = "unavailable"
+				fmt.Printf("Readiness check %s failed: %v\n", check.Name, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			result.Checks[check.Name] = status
+			if err != nil {
+				result.Status = "unavailable"
+			}
+		}()
+	}
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if result.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(result)
+}
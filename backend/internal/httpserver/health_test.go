@@ -0,0 +1,38 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"note-llm/internal/app"
+)
+
+func TestReadyzHidesCheckErrors(t *testing.T) {
+	s := &Server{
+		ReadinessTimeout: time.Second,
+		Checks: []app.Check{
+			{Name: "mongodb", Probe: func(context.Context) error { return nil }},
+			{Name: "qdrant", Probe: func(context.Context) error {
+				return errors.New("dial tcp qdrant.internal:6334: connection refused")
+			}},
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	s.ReadyzHandler(rec, httptest.NewRequest("GET", "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	body := rec.Body.String()
+	if strings.Contains(body, "qdrant.internal") {
+		t.Errorf("body leaks the check error: %s", body)
+	}
+	if !strings.Contains(body, `"qdrant":{"status":"unavailable"`) || !strings.Contains(body, `"mongodb":{"status":"ok"`) {
+		t.Errorf("body = %s, want the status of each check", body)
+	}
+}
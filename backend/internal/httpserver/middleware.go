@@ -2,16 +2,13 @@ package httpserver
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
-	"note-llm/internal/db"
-	"note-llm/internal/models"
-
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/spf13/viper"
-	"go.mongodb.org/mongo-driver/bson"
 )
 
 type contextKey string
@@ -19,7 +16,7 @@ type contextKey string
 const UserEmailKey contextKey = "userEmail"
 const UserIDKey contextKey = "userId"
 
-func JWTAuthMiddleware(next http.Handler) http.Handler {
+func (s *Server) JWTAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		auth := r.Header.Get("Authorization")
 		if !strings.HasPrefix(auth, "Bearer ") {
@@ -53,9 +50,13 @@ func JWTAuthMiddleware(next http.Handler) http.Handler {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		var user models.User
-		err = db.GetMongoDatabase().Collection("users").FindOne(ctx, bson.M{"email": emailClaim}).Decode(&user)
+		user, err := s.Users.FindByEmail(ctx, emailClaim)
 		if err != nil {
+			http.Error(w, "Database error", errorStatus(err))
+			fmt.Printf("Find user error: %v\n", err)
+			return
+		}
+		if user == nil {
 			http.Error(w, "User not found", http.StatusUnauthorized)
 			return
 		}
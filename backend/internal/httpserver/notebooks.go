@@ -0,0 +1,195 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"note-llm/internal/models"
+	"note-llm/internal/repository"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// notebookNameLength caps notebook names, in bytes.
+const notebookNameLength = 100
+
+func (s *Server) CreateNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	name, ok := decodeNotebookName(w, r)
+	if !ok {
+		return
+	}
+
+	userId := r.Context().Value(UserIDKey).(string)
+
+	now := time.Now()
+	notebook := models.Notebook{
+		ID:        uuid.New().String(),
+		UserID:    userId,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := s.Notebooks.Create(ctx, notebook)
+	if errors.Is(err, repository.ErrDuplicateNotebook) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to create notebook", errorStatus(err))
+		fmt.Printf("Insert notebook error: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(notebook)
+}
+
+func (s *Server) GetAllNotebooksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userId := r.Context().Value(UserIDKey).(string)
+
+	notebooks, err := s.Notebooks.List(ctx, userId)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find notebooks error: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notebooks)
+}
+
+func (s *Server) GetNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userId := r.Context().Value(UserIDKey).(string)
+
+	notebook, err := s.Notebooks.Get(ctx, chi.URLParam(r, "id"), userId)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find notebook error: %v\n", err)
+		return
+	}
+	if notebook == nil {
+		http.Error(w, "Notebook not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notebook)
+}
+
+func (s *Server) UpdateNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	name, ok := decodeNotebookName(w, r)
+	if !ok {
+		return
+	}
+
+	userId := r.Context().Value(UserIDKey).(string)
+	notebookID := chi.URLParam(r, "id")
+
+	found, err := s.Notebooks.Rename(ctx, notebookID, userId, name)
+	if errors.Is(err, repository.ErrDuplicateNotebook) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update notebook", errorStatus(err))
+		fmt.Printf("Update notebook error: %v\n", err)
+		return
+	}
+	if !found {
+		http.Error(w, "Notebook not found", http.StatusNotFound)
+		return
+	}
+
+	notebook, err := s.Notebooks.Get(ctx, notebookID, userId)
+	if err != nil || notebook == nil {
+		http.Error(w, "Failed to retrieve updated notebook", errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notebook)
+}
+
+// DeleteNotebookHandler deletes a notebook. Its notes are kept and simply
+// no longer belong to a notebook.
+func (s *Server) DeleteNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userId := r.Context().Value(UserIDKey).(string)
+	notebookID := chi.URLParam(r, "id")
+
+	found, err := s.Notebooks.Delete(ctx, notebookID, userId)
+	if err != nil {
+		http.Error(w, "Failed to delete notebook", errorStatus(err))
+		fmt.Printf("Delete notebook error: %v\n", err)
+		return
+	}
+	if !found {
+		http.Error(w, "Notebook not found", http.StatusNotFound)
+		return
+	}
+
+	// Scoped searches only match existing notebooks, so a failure here
+	// leaves harmless stale payload behind; the next re-index fixes it.
+	if err := s.Vectors.ClearNotebook(ctx, userId, notebookID); err != nil {
+		fmt.Printf("Clear notebook payload error: %v\n", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeNotebookName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req models.NotebookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return "", false
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > notebookNameLength {
+		http.Error(w, fmt.Sprintf("Notebook name must be 1 to %d characters", notebookNameLength), http.StatusBadRequest)
+		return "", false
+	}
+	return name, true
+}
+
+// checkNotebook writes an error response and returns false unless
+// notebookID is empty or names one of the user's notebooks.
+func (s *Server) checkNotebook(ctx context.Context, w http.ResponseWriter, notebookID, userID string) bool {
+	if notebookID == "" {
+		return true
+	}
+
+	notebook, err := s.Notebooks.Get(ctx, notebookID, userID)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find notebook error: %v\n", err)
+		return false
+	}
+	if notebook == nil {
+		http.Error(w, "Notebook not found", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,239 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"note-llm/internal/models"
+	"note-llm/internal/repository"
+	"note-llm/internal/textdiff"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// currentRevision names the note's present state in diff requests.
+const currentRevision = "current"
+
+func (s *Server) GetNoteRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	noteID := chi.URLParam(r, "id")
+	userId := r.Context().Value(UserIDKey).(string)
+
+	note, err := s.Notes.Get(ctx, noteID, userId)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find note error: %v\n", err)
+		return
+	}
+	if note == nil {
+		http.Error(w, "Note not found", http.StatusNotFound)
+		return
+	}
+
+	revisions, err := s.Notes.ListRevisions(ctx, noteID, userId)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find revisions error: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+func (s *Server) GetNoteRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rev, ok := s.loadRevision(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rev)
+}
+
+// DiffNoteRevisionsHandler compares two versions of a note line by line.
+// ?from= and ?to= take revision numbers or "current"; to defaults to
+// current.
+func (s *Server) DiffNoteRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	noteID := chi.URLParam(r, "id")
+	userId := r.Context().Value(UserIDKey).(string)
+
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" {
+		http.Error(w, "Missing from revision", http.StatusBadRequest)
+		return
+	}
+	if to == "" {
+		to = currentRevision
+	}
+
+	note, err := s.Notes.Get(ctx, noteID, userId)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find note error: %v\n", err)
+		return
+	}
+	if note == nil {
+		http.Error(w, "Note not found", http.StatusNotFound)
+		return
+	}
+
+	fromRev, err := s.noteVersion(ctx, note, from)
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+	toRev, err := s.noteVersion(ctx, note, to)
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NoteDiff{
+		From:      from,
+		To:        to,
+		FromTitle: fromRev.Title,
+		ToTitle:   toRev.Title,
+		Lines:     textdiff.Lines(fromRev.Content, toRev.Content),
+	})
+}
+
+func writeRevisionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errInvalidRevision):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errRevisionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find revision error: %v\n", err)
+	}
+}
+
+var (
+	errInvalidRevision  = errors.New("invalid revision")
+	errRevisionNotFound = errors.New("revision not found")
+)
+
+// noteVersion resolves a revision number, or "current" for the note's
+// present state, to that version of the note.
+func (s *Server) noteVersion(ctx context.Context, note *models.Note, ref string) (*models.NoteRevision, error) {
+	if ref == currentRevision {
+		return &models.NoteRevision{Title: note.Title, Content: note.Content}, nil
+	}
+
+	number, err := strconv.Atoi(ref)
+	if err != nil || number < 1 {
+		return nil, fmt.Errorf("%w %q", errInvalidRevision, ref)
+	}
+	rev, err := s.Notes.GetRevision(ctx, note.ID, note.UserID, number)
+	if err != nil {
+		return nil, err
+	}
+	if rev == nil {
+		return nil, fmt.Errorf("%w: %d", errRevisionNotFound, number)
+	}
+	return rev, nil
+}
+
+// RestoreNoteRevisionHandler makes an old revision the note's current
+// version, if the note still matches If-Match. The state being replaced is
+// saved as a new revision first, and the note is re-indexed.
+func (s *Server) RestoreNoteRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rev, ok := s.loadRevision(ctx, w, r)
+	if !ok {
+		return
+	}
+	userId := r.Context().Value(UserIDKey).(string)
+
+	change := models.UpdateNoteRequest{
+		Title:   rev.Title,
+		Content: rev.Content,
+		Tags:    rev.Tags,
+	}
+	if change.Tags == nil {
+		change.Tags = []string{}
+	}
+
+	// The notebook may have been deleted since; the note then stays out
+	// of any notebook.
+	notebookID := ""
+	if rev.NotebookID != "" {
+		notebook, err := s.Notebooks.Get(ctx, rev.NotebookID, userId)
+		if err != nil {
+			http.Error(w, "Database error", errorStatus(err))
+			fmt.Printf("Find notebook error: %v\n", err)
+			return
+		}
+		if notebook != nil {
+			notebookID = notebook.ID
+		}
+	}
+	change.NotebookID = &notebookID
+
+	current, ok := s.checkIfMatch(ctx, w, r, s.Notes.Get, rev.NoteID, userId)
+	if !ok {
+		return
+	}
+
+	note, err := s.Notes.Update(ctx, rev.NoteID, userId, current.Version, change)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Note not found or unauthorized", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		s.writeVersionConflict(ctx, w, s.Notes.Get, rev.NoteID, userId)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to restore revision", errorStatus(err))
+		fmt.Printf("Restore revision error: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", noteETag(note.Version))
+	json.NewEncoder(w).Encode(models.NewNoteResponse(*note))
+}
+
+// loadRevision fetches the revision named by the {id} and {rev} URL
+// parameters, writing an error response and returning false if it can't.
+func (s *Server) loadRevision(ctx context.Context, w http.ResponseWriter, r *http.Request) (*models.NoteRevision, bool) {
+	number, err := strconv.Atoi(chi.URLParam(r, "rev"))
+	if err != nil || number < 1 {
+		http.Error(w, "Invalid revision number", http.StatusBadRequest)
+		return nil, false
+	}
+
+	userId := r.Context().Value(UserIDKey).(string)
+
+	rev, err := s.Notes.GetRevision(ctx, chi.URLParam(r, "id"), userId, number)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find revision error: %v\n", err)
+		return nil, false
+	}
+	if rev == nil {
+		http.Error(w, "Revision not found", http.StatusNotFound)
+		return nil, false
+	}
+	return rev, true
+}
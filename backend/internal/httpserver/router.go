@@ -1,40 +1,109 @@
 package httpserver
 
 import (
+	"time"
+
+	"note-llm/internal/app"
+	"note-llm/internal/repository"
+	"note-llm/internal/search"
+	"note-llm/internal/vectorstore"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
+	"github.com/spf13/viper"
 )
 
 type Server struct {
-	Router chi.Router
+	Router        chi.Router
+	Notes         repository.NoteRepository
+	Users         repository.UserRepository
+	Notebooks     repository.NotebookRepository
+	Conversations repository.ConversationRepository
+	Vectors       vectorstore.VectorStore
+	// Checks are run by /readyz, each limited to ReadinessTimeout.
+	Checks           []app.Check
+	ReadinessTimeout time.Duration
+	// HistoryTurns is how many earlier question/answer pairs of a
+	// conversation are sent with a follow-up question.
+	HistoryTurns int
 }
 
-func New() *Server {
+// New serves the API from the stores in deps. Settings are read from
+// config here, once, rather than per request.
+func New(deps *app.Container) *Server {
+	viper.SetDefault("CONVERSATION_HISTORY_TURNS", 6)
+	viper.SetDefault("READINESS_TIMEOUT", 2*time.Second)
+
+	s := &Server{
+		Notes:            deps.Notes,
+		Users:            deps.Users,
+		Notebooks:        deps.Notebooks,
+		Conversations:    deps.Conversations,
+		Vectors:          deps.Vectors,
+		Checks:           deps.HealthChecks(),
+		ReadinessTimeout: viper.GetDuration("READINESS_TIMEOUT"),
+		HistoryTurns:     viper.GetInt("CONVERSATION_HISTORY_TURNS"),
+	}
 	r := chi.NewRouter()
 
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:5173"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-Match"},
+		ExposedHeaders:   []string{"Link", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
-	r.Get("/auth/{provider}", Provider)
-	r.Get("/auth/{provider}/callback", Callback)
+	r.Get("/healthz", s.HealthzHandler)
+	r.Get("/readyz", s.ReadyzHandler)
+
+	r.Get("/auth/{provider}", s.Provider)
+	r.Get("/auth/{provider}/callback", s.Callback)
 
 	r.Group(func(r chi.Router) {
-		r.Use(JWTAuthMiddleware)
+		r.Use(s.JWTAuthMiddleware)
 		r.Route("/notes", func(r chi.Router) {
-			r.Post("/", CreateNoteHandler)
-			r.Get("/", GetAllNotesHandler)
-			r.Get("/{id}", GetNoteHandler)
-			r.Put("/{id}", UpdateNoteHandler)
-			r.Delete("/{id}", DeleteNoteHandler)
-			r.Post("/ask", AskQuestionHandler)
+			r.Post("/", s.CreateNoteHandler)
+			r.Get("/", s.GetAllNotesHandler)
+			r.Get("/search", s.SearchNotesHandler)
+			r.Get("/{id}", s.GetNoteHandler)
+			r.Get("/{id}/index-status", s.GetNoteIndexStatusHandler)
+			r.Get("/{id}/revisions", s.GetNoteRevisionsHandler)
+			r.Get("/{id}/revisions/diff", s.DiffNoteRevisionsHandler)
+			r.Get("/{id}/revisions/{rev}", s.GetNoteRevisionHandler)
+			r.Post("/{id}/revisions/{rev}/restore", s.RestoreNoteRevisionHandler)
+			r.Put("/{id}", s.UpdateNoteHandler)
+			r.Delete("/{id}", s.DeleteNoteHandler)
+			r.Post("/ask", s.AskQuestionHandler)
+			r.Post("/ask/stream", s.AskQuestionStreamHandler)
+		})
+		r.Route("/trash", func(r chi.Router) {
+			r.Get("/", s.GetTrashHandler)
+			r.Post("/{id}/restore", s.RestoreTrashedNoteHandler)
+			r.Delete("/{id}", s.PurgeTrashedNoteHandler)
+		})
+		r.Route("/notebooks", func(r chi.Router) {
+			r.Post("/", s.CreateNotebookHandler)
+			r.Get("/", s.GetAllNotebooksHandler)
+			r.Get("/{id}", s.GetNotebookHandler)
+			r.Put("/{id}", s.UpdateNotebookHandler)
+			r.Delete("/{id}", s.DeleteNotebookHandler)
+		})
+		r.Route("/conversations", func(r chi.Router) {
+			r.Post("/", s.CreateConversationHandler)
+			r.Get("/", s.GetAllConversationsHandler)
+			r.Get("/{id}", s.GetConversationHandler)
+			r.Post("/{id}/messages", s.ContinueConversationHandler)
+			r.Delete("/{id}", s.DeleteConversationHandler)
 		})
 	})
 
-	return &Server{Router: r}
+	s.Router = r
+	return s
+}
+
+// searchStores are the stores that search and questions read from.
+func (s *Server) searchStores() search.Stores {
+	return search.Stores{Notes: s.Notes, Vectors: s.Vectors}
 }
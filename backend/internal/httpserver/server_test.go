@@ -0,0 +1,248 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"note-llm/internal/app"
+	"note-llm/internal/chunking"
+	"note-llm/internal/db"
+	"note-llm/internal/llm"
+	"note-llm/internal/models"
+	"note-llm/internal/qdrant"
+	"note-llm/internal/repository"
+	"note-llm/internal/vectorstore"
+
+	"github.com/spf13/viper"
+)
+
+const testEmail = "ada@example.com"
+
+func TestMain(m *testing.M) {
+	viper.Set("JWT_SECRET", "test-secret")
+	viper.Set("EMBEDDING_PROVIDER", "hash")
+	viper.Set("CHAT_PROVIDER", "fake")
+	viper.Set("RERANK_PROVIDER", "none")
+	m.Run()
+}
+
+// testClient calls a Server backed by the in-memory stores as a signed-in
+// user.
+type testClient struct {
+	t     *testing.T
+	srv   *Server
+	token string
+}
+
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+
+	vectors, err := vectorstore.OpenLocal("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := repository.NewMemoryNotes(vectors, chunking.DefaultOptions())
+	users := repository.NewMemoryUsers()
+	if err := users.Create(context.Background(), models.User{ID: "user-1", Email: testEmail}); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := generateJWT(testEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New(&app.Container{
+		InMemory:      true,
+		Notes:         notes,
+		Users:         users,
+		Notebooks:     repository.NewMemoryNotebooks(notes),
+		Conversations: repository.NewMemoryConversations(),
+		Vectors:       vectors,
+	})
+	return &testClient{t: t, srv: srv, token: token}
+}
+
+// do sends a request with an optional JSON body and If-Match header.
+func (c *testClient) do(method, path string, body any, ifMatch string) *httptest.ResponseRecorder {
+	c.t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+
+	rec := httptest.NewRecorder()
+	c.srv.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect fails the test unless the response has the given status, and
+// decodes its JSON body into out when out is not nil.
+func (c *testClient) expect(rec *httptest.ResponseRecorder, status int, out any) {
+	c.t.Helper()
+
+	if rec.Code != status {
+		c.t.Fatalf("status = %d, want %d; body: %s", rec.Code, status, rec.Body)
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			c.t.Fatalf("decode %s: %v", rec.Body, err)
+		}
+	}
+}
+
+func (c *testClient) createNote(title, content string) models.NoteResponse {
+	c.t.Helper()
+
+	var note models.NoteResponse
+	c.expect(c.do("POST", "/notes/", models.CreateNoteRequest{Title: title, Content: content}, ""), http.StatusCreated, &note)
+	return note
+}
+
+func TestNotesCRUD(t *testing.T) {
+	c := newTestClient(t)
+
+	rec := c.do("POST", "/notes/", models.CreateNoteRequest{Title: "Groceries", Content: "Milk and eggs", Tags: []string{"Home"}}, "")
+	var created models.NoteResponse
+	c.expect(rec, http.StatusCreated, &created)
+	if created.ID == "" || created.Title != "Groceries" {
+		t.Fatalf("created = %+v", created)
+	}
+	if got := rec.Header().Get("ETag"); got != `"1"` {
+		t.Errorf("create ETag = %s, want \"1\"", got)
+	}
+
+	c.expect(c.do("POST", "/notes/", models.CreateNoteRequest{Title: "No content"}, ""), http.StatusBadRequest, nil)
+
+	var fetched models.NoteResponse
+	c.expect(c.do("GET", "/notes/"+created.ID, nil, ""), http.StatusCreated, &fetched)
+	if fetched.Content != "Milk and eggs" {
+		t.Errorf("fetched content = %q", fetched.Content)
+	}
+
+	rec = c.do("PUT", "/notes/"+created.ID, models.UpdateNoteRequest{Title: "Groceries", Content: "Milk, eggs and bread"}, `"1"`)
+	var updated models.NoteResponse
+	c.expect(rec, http.StatusOK, &updated)
+	if updated.Content != "Milk, eggs and bread" {
+		t.Errorf("updated content = %q", updated.Content)
+	}
+	if got := rec.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("update ETag = %s, want \"2\"", got)
+	}
+
+	c.createNote("Second", "Another note")
+	var page models.NotePage
+	c.expect(c.do("GET", "/notes/?sort=title&limit=1", nil, ""), http.StatusOK, &page)
+	if len(page.Notes) != 1 || page.Notes[0].Title != "Groceries" || page.NextCursor == "" {
+		t.Fatalf("first page = %+v", page)
+	}
+	var next models.NotePage
+	c.expect(c.do("GET", "/notes/?sort=title&limit=1&cursor="+page.NextCursor, nil, ""), http.StatusOK, &next)
+	if len(next.Notes) != 1 || next.Notes[0].Title != "Second" || next.NextCursor != "" {
+		t.Fatalf("second page = %+v", next)
+	}
+
+	var revisions []models.NoteRevision
+	c.expect(c.do("GET", "/notes/"+created.ID+"/revisions", nil, ""), http.StatusOK, &revisions)
+	if len(revisions) != 1 || revisions[0].Number != 1 {
+		t.Errorf("revisions = %+v", revisions)
+	}
+}
+
+func TestNotebooks(t *testing.T) {
+	c := newTestClient(t)
+
+	var notebook models.Notebook
+	c.expect(c.do("POST", "/notebooks/", models.NotebookRequest{Name: "Work"}, ""), http.StatusCreated, &notebook)
+	c.expect(c.do("POST", "/notebooks/", models.NotebookRequest{Name: "Work"}, ""), http.StatusConflict, nil)
+
+	c.expect(c.do("POST", "/notes/", models.CreateNoteRequest{Title: "T", Content: "C", NotebookID: "nope"}, ""), http.StatusBadRequest, nil)
+	var note models.NoteResponse
+	c.expect(c.do("POST", "/notes/", models.CreateNoteRequest{Title: "T", Content: "C", NotebookID: notebook.ID}, ""), http.StatusCreated, &note)
+
+	c.expect(c.do("DELETE", "/notebooks/"+notebook.ID, nil, ""), http.StatusNoContent, nil)
+	rec := c.do("GET", "/notes/"+note.ID, nil, "")
+	var moved models.NoteResponse
+	c.expect(rec, http.StatusCreated, &moved)
+	if moved.NotebookID != "" || rec.Header().Get("ETag") != `"2"` {
+		t.Errorf("note after notebook delete = %+v, ETag %s", moved, rec.Header().Get("ETag"))
+	}
+}
+
+func TestSearchAndAskUseInjectedStores(t *testing.T) {
+	c := newTestClient(t)
+	target := c.createNote("Sourdough", "Feed the sourdough starter with rye flour every morning.")
+	c.createNote("Taxes", "File the quarterly taxes before the deadline.")
+
+	var status models.NoteIndexStatus
+	c.expect(c.do("GET", "/notes/"+target.ID+"/index-status", nil, ""), http.StatusOK, &status)
+	if status.IndexStatus != models.IndexStatusIndexed {
+		t.Fatalf("index status = %q, want indexed", status.IndexStatus)
+	}
+
+	var found struct {
+		Results []struct {
+			Note    models.NoteResponse `json:"note"`
+			Snippet string              `json:"snippet"`
+		} `json:"results"`
+	}
+	c.expect(c.do("GET", "/notes/search?q=sourdough+starter", nil, ""), http.StatusOK, &found)
+	if len(found.Results) == 0 || found.Results[0].Note.ID != target.ID {
+		t.Fatalf("search results = %+v", found.Results)
+	}
+	if !strings.Contains(found.Results[0].Snippet, "<mark>sourdough</mark>") {
+		t.Errorf("snippet = %q", found.Results[0].Snippet)
+	}
+
+	var answer struct {
+		NoteIDs []string `json:"note_ids"`
+	}
+	c.expect(c.do("POST", "/notes/ask", map[string]any{"question": "What do I feed the sourdough starter?", "limit": 1}, ""), http.StatusOK, &answer)
+	if len(answer.NoteIDs) != 1 || answer.NoteIDs[0] != target.ID {
+		t.Fatalf("answer note_ids = %v", answer.NoteIDs)
+	}
+
+	chat, err := llm.DefaultChatModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := chat.(*llm.ScriptedChatModel).Requests()
+	if len(requests) == 0 {
+		t.Fatal("chat model was not called")
+	}
+	last := requests[len(requests)-1].Messages
+	if prompt := last[len(last)-1].Content; !strings.Contains(prompt, "rye flour") {
+		t.Errorf("prompt lacks the retrieved note:\n%s", prompt)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("search failed: %w", db.ErrClosed), http.StatusServiceUnavailable},
+		{qdrant.ErrClosed, http.StatusServiceUnavailable},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := errorStatus(tt.err); got != tt.want {
+			t.Errorf("errorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"note-llm/internal/llm"
+	"note-llm/internal/rag"
+)
+
+// sseWriteTimeout bounds each individual event write. The server-wide
+// WriteTimeout is too short for a whole answer, so it is pushed back
+// before every event instead.
+const sseWriteTimeout = 10 * time.Second
+
+type sseWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func newSSEWriter(w http.ResponseWriter) *sseWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	return &sseWriter{w: w, rc: http.NewResponseController(w)}
+}
+
+// send writes one event with a JSON payload and flushes it to the client.
+func (s *sseWriter) send(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	// Not every ResponseWriter supports deadlines; that's fine.
+	_ = s.rc.SetWriteDeadline(time.Now().Add(sseWriteTimeout))
+
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// AskQuestionStreamHandler answers like AskQuestionHandler but streams the
+// answer as Server-Sent Events: "token" events carry {"delta"}, a final
+// "done" event carries the full answer with the source note IDs, and an
+// "error" event is sent if generation fails midway.
+func (s *Server) AskQuestionStreamHandler(w http.ResponseWriter, r *http.Request) {
+	var req askRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Question == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if err := req.Options.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	model, err := llm.ResolveChatModel(req.Model)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(string)
+	// Cancelled when the client disconnects, which aborts the LLM stream.
+	ctx := r.Context()
+
+	sse := newSSEWriter(w)
+	w.WriteHeader(http.StatusOK)
+	if err := sse.send("start", map[string]string{"model": model}); err != nil {
+		return
+	}
+
+	answer, err := rag.StreamAnswerFromUserNotes(ctx, s.searchStores(), rag.Request{UserID: userID, Question: req.Question, Model: model, Search: req.Options, Rerank: req.Rerank}, func(delta string) error {
+		return sse.send("token", map[string]string{"delta": delta})
+	})
+	if ctx.Err() != nil {
+		// Client went away; nobody is listening for the outcome.
+		return
+	}
+	if err != nil {
+		fmt.Print(err.Error())
+		sse.send("error", map[string]string{"error": "Failed to generate answer"})
+		return
+	}
+
+	sse.send("done", answer)
+}
@@ -0,0 +1,98 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"note-llm/internal/models"
+	"note-llm/internal/repository"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userId := r.Context().Value(UserIDKey).(string)
+
+	notes, err := s.Notes.ListTrash(ctx, userId)
+	if err != nil {
+		http.Error(w, "Database error", errorStatus(err))
+		fmt.Printf("Find trash error: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewNoteResponses(notes))
+}
+
+// RestoreTrashedNoteHandler moves a note that still matches If-Match out
+// of the trash and queues it for re-indexing.
+func (s *Server) RestoreTrashedNoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	noteID := chi.URLParam(r, "id")
+	userId := r.Context().Value(UserIDKey).(string)
+
+	current, ok := s.checkIfMatch(ctx, w, r, s.Notes.GetTrashed, noteID, userId)
+	if !ok {
+		return
+	}
+
+	note, err := s.Notes.Restore(ctx, noteID, userId, current.Version)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Note not found in trash", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		s.writeVersionConflict(ctx, w, s.Notes.GetTrashed, noteID, userId)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to restore note", errorStatus(err))
+		fmt.Printf("Restore note error: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", noteETag(note.Version))
+	json.NewEncoder(w).Encode(models.NewNoteResponse(*note))
+}
+
+// PurgeTrashedNoteHandler permanently deletes a note that still matches
+// If-Match from the trash along with its revisions and vectors.
+func (s *Server) PurgeTrashedNoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	noteID := chi.URLParam(r, "id")
+	userId := r.Context().Value(UserIDKey).(string)
+
+	current, ok := s.checkIfMatch(ctx, w, r, s.Notes.GetTrashed, noteID, userId)
+	if !ok {
+		return
+	}
+
+	err := s.Notes.Purge(ctx, noteID, userId, current.Version)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Note not found in trash", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		s.writeVersionConflict(ctx, w, s.Notes.GetTrashed, noteID, userId)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete note", errorStatus(err))
+		fmt.Printf("Purge note error: %v\n", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,39 @@
+package httpserver
+
+import (
+	"net/http"
+	"testing"
+
+	"note-llm/internal/models"
+)
+
+func TestTrashFlow(t *testing.T) {
+	c := newTestClient(t)
+	note := c.createNote("Old idea", "Maybe later")
+
+	c.expect(c.do("DELETE", "/notes/"+note.ID, nil, `"1"`), http.StatusNoContent, nil)
+	c.expect(c.do("GET", "/notes/"+note.ID, nil, ""), http.StatusNotFound, nil)
+
+	var trash []models.NoteResponse
+	c.expect(c.do("GET", "/trash/", nil, ""), http.StatusOK, &trash)
+	if len(trash) != 1 || trash[0].ID != note.ID || trash[0].DeletedAt == nil {
+		t.Fatalf("trash = %+v", trash)
+	}
+
+	var restored models.NoteResponse
+	c.expect(c.do("POST", "/trash/"+note.ID+"/restore", nil, `"1"`), http.StatusOK, &restored)
+	if restored.DeletedAt != nil {
+		t.Errorf("restored note still has deleted_at")
+	}
+	c.expect(c.do("GET", "/notes/"+note.ID, nil, ""), http.StatusCreated, nil)
+	c.expect(c.do("DELETE", "/trash/"+note.ID, nil, `"1"`), http.StatusNotFound, nil)
+
+	c.expect(c.do("DELETE", "/notes/"+note.ID, nil, `"1"`), http.StatusNoContent, nil)
+	c.expect(c.do("DELETE", "/trash/"+note.ID, nil, `"1"`), http.StatusNoContent, nil)
+	var emptied []models.NoteResponse
+	c.expect(c.do("GET", "/trash/", nil, ""), http.StatusOK, &emptied)
+	if len(emptied) != 0 {
+		t.Errorf("trash after purge = %+v", emptied)
+	}
+	c.expect(c.do("POST", "/trash/"+note.ID+"/restore", nil, `"1"`), http.StatusNotFound, nil)
+}
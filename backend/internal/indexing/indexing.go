@@ -0,0 +1,126 @@
+package indexing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"note-llm/internal/chunking"
+	"note-llm/internal/llm"
+	"note-llm/internal/models"
+	"note-llm/internal/vectorstore"
+)
+
+// EmbeddedNote holds a note's chunks and one vector per chunk, in order.
+type EmbeddedNote struct {
+	Chunks  []chunking.Chunk
+	Vectors [][]float32
+}
+
+// EmbedNote chunks a note as opts says and embeds every chunk. Each chunk
+// is embedded together with the note title and its section heading so
+// that short chunks keep their context.
+func EmbedNote(ctx context.Context, note models.Note, opts chunking.Options) (*EmbeddedNote, error) {
+	chunks := chunking.Split(note.Content, opts)
+	if len(chunks) == 0 {
+		// Title-only note: still index it so it can be found.
+		chunks = []chunking.Chunk{{Index: 0}}
+	}
+
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = chunkEmbeddingText(note.Title, c)
+	}
+
+	vectors, err := llm.GetEmbeddings(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed note %s: %w", note.ID, err)
+	}
+
+	return &EmbeddedNote{Chunks: chunks, Vectors: vectors}, nil
+}
+
+func chunkEmbeddingText(title string, c chunking.Chunk) string {
+	parts := []string{title}
+	if c.Heading != "" && !strings.Contains(c.Text, c.Heading) {
+		parts = append(parts, c.Heading)
+	}
+	if c.Text != "" {
+		parts = append(parts, c.Text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// MeanVector returns the normalized mean of vectors, or nil when there are
+// none.
+func MeanVector(vectors [][]float32) []float32 {
+	if len(vectors) == 0 {
+		return nil
+	}
+
+	mean := make([]float32, len(vectors[0]))
+	for _, v := range vectors {
+		for i := range mean {
+			mean[i] += v[i]
+		}
+	}
+
+	var norm float64
+	for _, x := range mean {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return mean
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range mean {
+		mean[i] *= scale
+	}
+	return mean
+}
+
+// Store writes the chunk vectors of a note to store, replacing whatever
+// was indexed for it before.
+func Store(ctx context.Context, store vectorstore.VectorStore, note models.Note, e *EmbeddedNote) error {
+	points := make([]vectorstore.ChunkPoint, len(e.Chunks))
+	for i, c := range e.Chunks {
+		points[i] = vectorstore.ChunkPoint{
+			Index:  c.Index,
+			Start:  c.Start,
+			End:    c.End,
+			Vector: e.Vectors[i],
+		}
+	}
+	return store.Upsert(ctx, vectorstore.NotePayload{
+		NoteID:     note.ID,
+		UserID:     note.UserID,
+		NotebookID: note.NotebookID,
+		Tags:       note.Tags,
+		CreatedAt:  note.CreatedAt,
+		ModifiedAt: note.ModifiedAt,
+		Version:    note.Version,
+	}, points)
+}
+
+// IndexNote embeds a note and writes its chunks to store, recording the
+// outcome in the note's index fields. The returned error is the indexing
+// failure, if any; the note is updated either way.
+func IndexNote(ctx context.Context, store vectorstore.VectorStore, note *models.Note, opts chunking.Options) error {
+	embedded, err := EmbedNote(ctx, *note, opts)
+	if err == nil {
+		err = Store(ctx, store, *note, embedded)
+	}
+	if err != nil {
+		note.IndexStatus = models.IndexStatusFailed
+		note.IndexError = err.Error()
+		return err
+	}
+
+	now := time.Now()
+	note.IndexStatus = models.IndexStatusIndexed
+	note.IndexError = ""
+	note.IndexedAt = &now
+	return nil
+}
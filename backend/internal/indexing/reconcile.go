@@ -0,0 +1,89 @@
+package indexing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"note-llm/internal/db"
+	"note-llm/internal/vectorstore"
+)
+
+// NoteSet tells which notes exist. It is implemented by the note
+// repositories.
+type NoteSet interface {
+	// ExistingIDs reports which of the given notes, of any user, exist
+	// outside the trash.
+	ExistingIDs(ctx context.Context, noteIDs []string) (map[string]bool, error)
+}
+
+// PurgeOrphans deletes points in store whose note no longer exists in
+// notes, or is in the trash, and returns how many notes' vectors were
+// removed.
+func PurgeOrphans(ctx context.Context, store vectorstore.VectorStore, notes NoteSet) (int, error) {
+	purged := 0
+	err := store.ScrollNoteIDs(ctx, 256, func(noteIDs []string) error {
+		existing, err := notes.ExistingIDs(ctx, noteIDs)
+		if err != nil {
+			return fmt.Errorf("failed to look up notes: %w", err)
+		}
+
+		var orphans []string
+		for _, id := range noteIDs {
+			if !existing[id] {
+				orphans = append(orphans, id)
+			}
+		}
+		if err := store.Delete(ctx, orphans...); err != nil {
+			return fmt.Errorf("failed to delete orphaned vectors: %w", err)
+		}
+		purged += len(orphans)
+		return nil
+	})
+	return purged, err
+}
+
+// BackfillLegacyPoints queues re-indexing for notes whose vectors are
+// still a single whole-note point from before notes were chunked, or
+// chunks indexed before the note version was recorded with them, and
+// returns how many notes were queued. Re-indexing replaces those points,
+// so once the outbox has caught up there is nothing left to backfill.
+func BackfillLegacyPoints(ctx context.Context) (int, error) {
+	store, err := vectorstore.Default()
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	err = store.ScrollLegacyNoteIDs(ctx, 256, func(noteIDs []string) error {
+		n, err := db.EnqueueReindex(ctx, noteIDs)
+		if err != nil {
+			return fmt.Errorf("failed to queue re-indexing: %w", err)
+		}
+		queued += n
+		return nil
+	})
+	return queued, err
+}
+
+// RunReconciler purges orphaned vectors from store once at start and then
+// every interval until ctx is cancelled.
+func RunReconciler(ctx context.Context, store vectorstore.VectorStore, notes NoteSet, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := PurgeOrphans(ctx, store, notes)
+		if err != nil {
+			fmt.Printf("Reconcile error: %v\n", err)
+		} else if purged > 0 {
+			fmt.Printf("Reconcile: purged vectors of %d deleted notes\n", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
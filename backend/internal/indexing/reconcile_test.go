@@ -0,0 +1,59 @@
+package indexing
+
+import (
+	"context"
+	"testing"
+
+	"note-llm/internal/models"
+	"note-llm/internal/vectorstore"
+)
+
+// noteSet is a NoteSet over a fixed list of note IDs.
+type noteSet map[string]bool
+
+func (s noteSet) ExistingIDs(ctx context.Context, noteIDs []string) (map[string]bool, error) {
+	existing := map[string]bool{}
+	for _, id := range noteIDs {
+		if s[id] {
+			existing[id] = true
+		}
+	}
+	return existing, nil
+}
+
+func TestPurgeOrphans(t *testing.T) {
+	ctx := context.Background()
+	store, err := vectorstore.OpenLocal("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"kept", "orphan"} {
+		chunks := []vectorstore.ChunkPoint{{Index: 0, End: 1, Vector: []float32{1, 0}}}
+		if err := store.Upsert(ctx, vectorstore.NotePayload{NoteID: id, UserID: "u1"}, chunks); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	purged, err := PurgeOrphans(ctx, store, noteSet{"kept": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("purged %d notes, want 1", purged)
+	}
+
+	var left []string
+	err = store.ScrollNoteIDs(ctx, 10, func(noteIDs []string) error {
+		left = append(left, noteIDs...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || left[0] != "kept" {
+		t.Fatalf("notes left = %v, want [kept]", left)
+	}
+	if n, _ := store.Count(ctx, "u1", models.NoteFilter{}); n != 1 {
+		t.Fatalf("Count = %d, want 1", n)
+	}
+}
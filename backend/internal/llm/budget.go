@@ -0,0 +1,31 @@
+package llm
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	viper.SetDefault("CONTEXT_TOKEN_BUDGET", 6000)
+}
+
+// ContextBudget returns how many tokens of note excerpts may go into a
+// prompt for model. CONTEXT_TOKEN_BUDGET is the default; entries in
+// CONTEXT_TOKEN_BUDGETS ("model=tokens", comma separated) override it for
+// models with a smaller or larger context window.
+func ContextBudget(model string) int {
+	for _, entry := range viper.GetStringSlice("CONTEXT_TOKEN_BUDGETS") {
+		for _, pair := range strings.Split(entry, ",") {
+			name, tokens, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(name) != model {
+				continue
+			}
+			if n, err := strconv.Atoi(strings.TrimSpace(tokens)); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return viper.GetInt("CONTEXT_TOKEN_BUDGET")
+}
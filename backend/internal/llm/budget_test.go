@@ -0,0 +1,18 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestContextBudget(t *testing.T) {
+	viper.Set("CONTEXT_TOKEN_BUDGETS", "small=1000,large=32000")
+	t.Cleanup(func() { viper.Set("CONTEXT_TOKEN_BUDGETS", nil) })
+
+	for model, want := range map[string]int{"small": 1000, "large": 32000, "other": 6000} {
+		if got := ContextBudget(model); got != want {
+			t.Errorf("ContextBudget(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// ErrUnknownModel is returned when a request asks for a chat model that is
+// not in the CHAT_MODELS allow-list.
+var ErrUnknownModel = errors.New("unknown chat model")
+
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is a single completion call. An empty Model means the
+// provider's configured default.
+type ChatRequest struct {
+	Model    string
+	Messages []ChatMessage
+}
+
+// ChatModel produces a completion for a list of messages.
+type ChatModel interface {
+	Complete(ctx context.Context, req ChatRequest) (string, error)
+}
+
+// StreamingChatModel is implemented by providers that can deliver a
+// completion incrementally. onDelta is called with each new piece of text;
+// returning an error from it aborts the stream. The full text is returned.
+type StreamingChatModel interface {
+	ChatModel
+	Stream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (string, error)
+}
+
+// StreamChat streams a completion when the provider supports it and
+// otherwise delivers the whole completion as a single delta.
+func StreamChat(ctx context.Context, chat ChatModel, req ChatRequest, onDelta func(delta string) error) (string, error) {
+	if streamer, ok := chat.(StreamingChatModel); ok {
+		return streamer.Stream(ctx, req, onDelta)
+	}
+
+	text, err := chat.Complete(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if err := onDelta(text); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// ChatModelFactory builds a ChatModel from the current viper config.
+type ChatModelFactory func() (ChatModel, error)
+
+var (
+	chatModelsMu sync.RWMutex
+	chatModels   = map[string]ChatModelFactory{}
+
+	defaultChatModel     ChatModel
+	defaultChatModelOnce sync.Once
+	defaultChatModelErr  error
+)
+
+func init() {
+	viper.SetDefault("CHAT_PROVIDER", "openai")
+}
+
+// RegisterChatModel makes a chat provider selectable through the
+// CHAT_PROVIDER config key. It panics on duplicate names.
+func RegisterChatModel(name string, factory ChatModelFactory) {
+	chatModelsMu.Lock()
+	defer chatModelsMu.Unlock()
+
+	name = strings.ToLower(name)
+	if _, exists := chatModels[name]; exists {
+		panic(fmt.Sprintf("llm: chat model %q already registered", name))
+	}
+	chatModels[name] = factory
+}
+
+// NewChatModel builds the registered chat provider with the given name.
+func NewChatModel(name string) (ChatModel, error) {
+	chatModelsMu.RLock()
+	factory, ok := chatModels[strings.ToLower(name)]
+	chatModelsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown chat provider %q (available: %s)", name, strings.Join(ChatModelNames(), ", "))
+	}
+	return factory()
+}
+
+// ChatModelNames lists the registered chat providers.
+func ChatModelNames() []string {
+	chatModelsMu.RLock()
+	defer chatModelsMu.RUnlock()
+
+	names := make([]string, 0, len(chatModels))
+	for name := range chatModels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultChatModel returns the provider selected by CHAT_PROVIDER
+// (defaults to "openai"). It is built once and shared.
+func DefaultChatModel() (ChatModel, error) {
+	defaultChatModelOnce.Do(func() {
+		defaultChatModel, defaultChatModelErr = NewChatModel(viper.GetString("CHAT_PROVIDER"))
+	})
+	return defaultChatModel, defaultChatModelErr
+}
+
+// ResolveChatModel validates a per-request model name. An empty name
+// resolves to CHAT_MODEL; anything else must be CHAT_MODEL itself or listed
+// in CHAT_MODELS.
+func ResolveChatModel(name string) (string, error) {
+	defaultModel := chatModelFromConfig()
+
+	name = strings.TrimSpace(name)
+	if name == "" || name == defaultModel {
+		return defaultModel, nil
+	}
+	for _, allowed := range viper.GetStringSlice("CHAT_MODELS") {
+		for _, m := range strings.Split(allowed, ",") {
+			if strings.TrimSpace(m) == name {
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownModel, name)
+}
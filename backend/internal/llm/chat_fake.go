@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	RegisterChatModel("fake", func() (ChatModel, error) {
+		return NewScriptedChatModel(viper.GetStringSlice("CHAT_FAKE_RESPONSES")...), nil
+	})
+}
+
+// ScriptedChatModel replays canned responses in order and records every
+// request it receives. Once the script runs out it answers with a short
+// deterministic echo of the last user message, so it never fails.
+type ScriptedChatModel struct {
+	mu        sync.Mutex
+	responses []string
+	requests  []ChatRequest
+}
+
+func NewScriptedChatModel(responses ...string) *ScriptedChatModel {
+	return &ScriptedChatModel{responses: responses}
+}
+
+func (m *ScriptedChatModel) Complete(ctx context.Context, req ChatRequest) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, req)
+	if len(m.responses) > 0 {
+		next := m.responses[0]
+		m.responses = m.responses[1:]
+		return next, nil
+	}
+
+	var last string
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == RoleUser {
+			last = req.Messages[i].Content
+			break
+		}
+	}
+	if idx := strings.LastIndex(last, "Question:\n"); idx >= 0 {
+		last = last[idx+len("Question:\n"):]
+	}
+	last, _, _ = strings.Cut(strings.TrimSpace(last), "\n")
+	return fmt.Sprintf("[fake] %s", last), nil
+}
+
+// Stream delivers the scripted response word by word.
+func (m *ScriptedChatModel) Stream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (string, error) {
+	text, err := m.Complete(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	for _, word := range strings.SplitAfter(text, " ") {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if err := onDelta(word); err != nil {
+			return "", err
+		}
+	}
+	return text, nil
+}
+
+// Requests returns a copy of every request received so far.
+func (m *ScriptedChatModel) Requests() []ChatRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ChatRequest(nil), m.requests...)
+}
@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	RegisterChatModel("openai", newOpenAIChatModel)
+	RegisterChatModel("local", newLocalChatModel)
+
+	// Defaults are registered before any request can read config:
+	// viper's maps aren't safe to write while other goroutines read them.
+	viper.SetDefault("CHAT_BASE_URL", "http://localhost:11434/v1")
+	viper.SetDefault("CHAT_MODEL", "gpt-4.1-nano")
+}
+
+// openAIChatModel calls the chat completions API. The "local" provider
+// reuses it against any OpenAI-compatible server (Ollama, llama.cpp,
+// vLLM, LM Studio, ...).
+type openAIChatModel struct {
+	client       openai.Client
+	defaultModel string
+}
+
+func newOpenAIChatModel() (ChatModel, error) {
+	InitOpenAIClient()
+	if initErr != nil {
+		return nil, initErr
+	}
+	return &openAIChatModel{client: client, defaultModel: chatModelFromConfig()}, nil
+}
+
+func newLocalChatModel() (ChatModel, error) {
+	// Local servers usually ignore the key, but the client always sends one.
+	apiKey := viper.GetString("CHAT_API_KEY")
+	if apiKey == "" {
+		apiKey = "local"
+	}
+
+	c := openai.NewClient(
+		option.WithBaseURL(viper.GetString("CHAT_BASE_URL")),
+		option.WithAPIKey(apiKey),
+	)
+	return &openAIChatModel{client: c, defaultModel: chatModelFromConfig()}, nil
+}
+
+func chatModelFromConfig() string {
+	return viper.GetString("CHAT_MODEL")
+}
+
+// HealthCheck looks up the default model, which needs a valid key and a
+// server that serves the model.
+func (m *openAIChatModel) HealthCheck(ctx context.Context) error {
+	if _, err := m.client.Models.Get(ctx, m.defaultModel, option.WithMaxRetries(0)); err != nil {
+		return fmt.Errorf("chat model %s unavailable: %w", m.defaultModel, err)
+	}
+	return nil
+}
+
+func (m *openAIChatModel) Complete(ctx context.Context, req ChatRequest) (string, error) {
+	resp, err := m.client.Chat.Completions.New(ctx, m.params(req))
+	if err != nil {
+		return "", fmt.Errorf("LLM call failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("LLM call failed: empty response")
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (m *openAIChatModel) Stream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (string, error) {
+	stream := m.client.Chat.Completions.NewStreaming(ctx, m.params(req))
+	defer stream.Close()
+
+	var sb strings.Builder
+	for stream.Next() {
+		chunk := stream.Current()
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		delta := chunk.Choices[0].Delta.Content
+		sb.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return sb.String(), err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return sb.String(), fmt.Errorf("LLM call failed: %w", err)
+	}
+
+	return sb.String(), nil
+}
+
+func (m *openAIChatModel) params(req ChatRequest) openai.ChatCompletionNewParams {
+	model := req.Model
+	if model == "" {
+		model = m.defaultModel
+	}
+
+	messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case RoleSystem:
+			messages = append(messages, openai.SystemMessage(msg.Content))
+		case RoleAssistant:
+			messages = append(messages, openai.AssistantMessage(msg.Content))
+		default:
+			messages = append(messages, openai.UserMessage(msg.Content))
+		}
+	}
+
+	return openai.ChatCompletionNewParams{
+		Model:    model,
+		Messages: messages,
+	}
+}
@@ -5,16 +5,51 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/openai/openai-go"
+	"note-llm/internal/tokenizer"
 )
 
-// Summarize builds a prompt and queries the LLM
-func Summarize(question string, notes []string) (string, error) {
-	InitOpenAIClient()
-	if initErr != nil {
-		return "", initErr
+// Excerpt is a piece of a user's note passed to the model as context. ID is
+// the label the model cites it by, e.g. "S1".
+type Excerpt struct {
+	ID    string
+	Title string
+	Text  string
+}
+
+// Summarize builds a prompt and queries the configured chat model. An empty
+// model uses the provider default. history holds earlier turns of the
+// conversation, oldest first, and may be empty.
+func Summarize(ctx context.Context, model, question string, history []ChatMessage, notes []Excerpt) (string, error) {
+	chat, err := DefaultChatModel()
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := chat.Complete(ctx, summaryRequest(model, question, history, notes))
+	if err != nil {
+		return "", fmt.Errorf("LLM call failed: %w", err)
 	}
 
+	return answer, nil
+}
+
+// SummarizeStream is Summarize, delivering the answer through onDelta as
+// it is generated. It returns the complete answer.
+func SummarizeStream(ctx context.Context, model, question string, history []ChatMessage, notes []Excerpt, onDelta func(delta string) error) (string, error) {
+	chat, err := DefaultChatModel()
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := StreamChat(ctx, chat, summaryRequest(model, question, history, notes), onDelta)
+	if err != nil {
+		return "", fmt.Errorf("LLM call failed: %w", err)
+	}
+
+	return answer, nil
+}
+
+func summaryRequest(model, question string, history []ChatMessage, notes []Excerpt) ChatRequest {
 	contextText := buildContextFromNotes(notes)
 	fullPrompt := fmt.Sprintf(`You are an AI assistant built into a personal note-taking app. You only respond using the user's notes, documents, and other saved content. Your job is to help the user recall, summarize, and answer questions based only on their own notes.
 
@@ -24,6 +59,8 @@ Be concise, clear, and helpful. Use bullet points or formatting where useful. If
 
 Never hallucinate facts. If a topic is mentioned in the notes but incomplete, clearly say so.
 
+Each note below starts with an ID in square brackets, like [S1]. Cite the notes you use inline by putting their ID in square brackets right after the statement they support, e.g. "The meeting is on Friday [S2]." Only cite IDs that appear below.
+
 User's Notes:
 %s
 
@@ -32,24 +69,35 @@ Question:
 
 Answer:`, contextText, question)
 
-	resp, err := client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
-		Model: "gpt-4.1-nano",
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.UserMessage(fullPrompt),
-		},
-	})
-	if err != nil {
-		return "", fmt.Errorf("LLM call failed: %w", err)
-	}
+	// Earlier turns go first as plain messages so the model can resolve
+	// references like "it" or "the second one"; the notes only accompany
+	// the current question.
+	messages := make([]ChatMessage, 0, len(history)+1)
+	messages = append(messages, history...)
+	messages = append(messages, ChatMessage{Role: RoleUser, Content: fullPrompt})
 
-	return resp.Choices[0].Message.Content, nil
+	return ChatRequest{
+		Model:    model,
+		Messages: messages,
+	}
 }
 
-// buildContextFromNotes creates a string representation of user notes
-func buildContextFromNotes(notes []string) string {
+// buildContextFromNotes creates a string representation of user notes,
+// each labelled with its citation ID
+func buildContextFromNotes(notes []Excerpt) string {
 	var sb strings.Builder
-	for i, note := range notes {
-		sb.WriteString(fmt.Sprintf("Note %d:\n%s\n\n", i+1, note))
+	for _, note := range notes {
+		sb.WriteString(formatExcerpt(note))
 	}
 	return sb.String()
 }
+
+func formatExcerpt(note Excerpt) string {
+	return fmt.Sprintf("[%s] %s\n%s\n\n", note.ID, note.Title, note.Text)
+}
+
+// ExcerptTokens returns how many prompt tokens an excerpt takes, including
+// its ID and title line.
+func ExcerptTokens(note Excerpt) int {
+	return tokenizer.Count(formatExcerpt(note))
+}
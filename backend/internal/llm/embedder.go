@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// Embedder turns a batch of texts into one vector per text, in input order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedderFactory builds an Embedder from the current viper config.
+type EmbedderFactory func() (Embedder, error)
+
+var (
+	embeddersMu sync.RWMutex
+	embedders   = map[string]EmbedderFactory{}
+
+	defaultEmbedder     Embedder
+	defaultEmbedderOnce sync.Once
+	defaultEmbedderErr  error
+)
+
+// RegisterEmbedder makes an embedding provider selectable through the
+// EMBEDDING_PROVIDER config key. It panics on duplicate names.
+func RegisterEmbedder(name string, factory EmbedderFactory) {
+	embeddersMu.Lock()
+	defer embeddersMu.Unlock()
+
+	name = strings.ToLower(name)
+	if _, exists := embedders[name]; exists {
+		panic(fmt.Sprintf("llm: embedder %q already registered", name))
+	}
+	embedders[name] = factory
+}
+
+// NewEmbedder builds the registered embedder with the given name.
+func NewEmbedder(name string) (Embedder, error) {
+	embeddersMu.RLock()
+	factory, ok := embedders[strings.ToLower(name)]
+	embeddersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding provider %q (available: %s)", name, strings.Join(EmbedderNames(), ", "))
+	}
+	return factory()
+}
+
+// EmbedderNames lists the registered embedding providers.
+func EmbedderNames() []string {
+	embeddersMu.RLock()
+	defer embeddersMu.RUnlock()
+
+	names := make([]string, 0, len(embedders))
+	for name := range embedders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultEmbedder returns the embedder selected by EMBEDDING_PROVIDER
+// (defaults to "openai"). It is built once and shared.
+func DefaultEmbedder() (Embedder, error) {
+	defaultEmbedderOnce.Do(func() {
+		viper.SetDefault("EMBEDDING_PROVIDER", "openai")
+		defaultEmbedder, defaultEmbedderErr = NewEmbedder(viper.GetString("EMBEDDING_PROVIDER"))
+	})
+	return defaultEmbedder, defaultEmbedderErr
+}
+
+// GetEmbeddings embeds texts with the configured embedding provider.
+func GetEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embedder, err := DefaultEmbedder()
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embedding failed: got %d vectors for %d texts", len(vectors), len(texts))
+	}
+	return vectors, nil
+}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	RegisterEmbedder("hash", newHashEmbedder)
+}
+
+// hashEmbedder is a deterministic, dependency-free embedder based on
+// feature hashing of lowercased words and their character trigrams. It is
+// not semantically meaningful, but texts sharing vocabulary land close to
+// each other, which is enough to exercise the pipeline offline and in CI.
+type hashEmbedder struct {
+	dimensions int
+}
+
+func newHashEmbedder() (Embedder, error) {
+	viper.SetDefault("EMBEDDING_DIMENSIONS", 256)
+	dimensions := viper.GetInt("EMBEDDING_DIMENSIONS")
+	if dimensions <= 0 {
+		return nil, fmt.Errorf("EMBEDDING_DIMENSIONS must be positive, got %d", dimensions)
+	}
+	return &hashEmbedder{dimensions: dimensions}, nil
+}
+
+func (e *hashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	result := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result[i] = e.embed(text)
+	}
+	return result, nil
+}
+
+func (e *hashEmbedder) embed(text string) []float32 {
+	vector := make([]float32, e.dimensions)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		e.add(vector, word, 1)
+
+		padded := []rune("^" + word + "$")
+		for j := 0; j+3 <= len(padded); j++ {
+			e.add(vector, string(padded[j:j+3]), 0.5)
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return vector
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range vector {
+		vector[i] *= scale
+	}
+	return vector
+}
+
+// add hashes feature into a bucket; one hash bit picks the sign so that
+// collisions tend to cancel out instead of piling up.
+func (e *hashEmbedder) add(vector []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+
+	bucket := int(sum % uint64(e.dimensions))
+	if sum&(1<<63) != 0 {
+		weight = -weight
+	}
+	vector[bucket] += weight
+}
@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	RegisterEmbedder("ollama", newLocalEmbedder)
+}
+
+// localEmbedder talks to an Ollama-style embedding server
+// (POST {base}/api/embed with {"model", "input"}) running on the same
+// machine or network, so embeddings can be produced without OpenAI.
+type localEmbedder struct {
+	httpClient *http.Client
+	baseURL    string
+	model      string
+}
+
+func newLocalEmbedder() (Embedder, error) {
+	viper.SetDefault("EMBEDDING_BASE_URL", "http://localhost:11434")
+	viper.SetDefault("EMBEDDING_TIMEOUT", 30*time.Second)
+
+	model := viper.GetString("EMBEDDING_MODEL")
+	if model == "" {
+		return nil, fmt.Errorf("Missing EMBEDDING_MODEL in config")
+	}
+
+	return &localEmbedder{
+		httpClient: &http.Client{Timeout: viper.GetDuration("EMBEDDING_TIMEOUT")},
+		baseURL:    strings.TrimRight(viper.GetString("EMBEDDING_BASE_URL"), "/"),
+		model:      model,
+	}, nil
+}
+
+type localEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type localEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error"`
+}
+
+func (e *localEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(localEmbedRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+
+	var out localEmbedResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("embedding failed: %s: %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding failed: %s: %s", resp.Status, out.Error)
+	}
+
+	return out.Embeddings, nil
+}
+
+// HealthCheck lists the server's models (GET {base}/api/tags), which
+// answers without loading any of them.
+func (e *localEmbedder) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.baseURL+"/api/tags", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("embedding server unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("embedding server unavailable: %s", resp.Status)
+	}
+	return nil
+}
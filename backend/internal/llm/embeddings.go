@@ -27,31 +27,80 @@ func InitOpenAIClient() {
 	})
 }
 
-func GetEmbeddings(texts []string) ([][]float32, error) {
+func init() {
+	RegisterEmbedder("openai", newOpenAIEmbedder)
+}
+
+// openAIEmbedder calls the OpenAI embeddings endpoint. Setting
+// EMBEDDING_BASE_URL points it at any OpenAI-compatible server instead
+// (e.g. llama.cpp started with --embeddings).
+type openAIEmbedder struct {
+	client     openai.Client
+	model      string
+	dimensions int64
+}
+
+func newOpenAIEmbedder() (Embedder, error) {
+	viper.SetDefault("EMBEDDING_MODEL", openai.EmbeddingModelTextEmbedding3Small)
+
+	e := &openAIEmbedder{
+		model:      viper.GetString("EMBEDDING_MODEL"),
+		dimensions: viper.GetInt64("EMBEDDING_DIMENSIONS"),
+	}
+
+	if baseURL := viper.GetString("EMBEDDING_BASE_URL"); baseURL != "" {
+		opts := []option.RequestOption{option.WithBaseURL(baseURL)}
+		if apiKey := viper.GetString("OPENAI_API"); apiKey != "" {
+			opts = append(opts, option.WithAPIKey(apiKey))
+		}
+		e.client = openai.NewClient(opts...)
+		return e, nil
+	}
+
 	InitOpenAIClient()
 	if initErr != nil {
 		return nil, initErr
 	}
+	e.client = client
+	return e, nil
+}
 
-	// Call embeddings endpoint
-	res, err := client.Embeddings.New(context.Background(), openai.EmbeddingNewParams{
-		Model: openai.EmbeddingModelTextEmbedding3Small, // Fixed model constant
+// HealthCheck looks up the embedding model, which needs a valid key and a
+// server that serves the model.
+func (e *openAIEmbedder) HealthCheck(ctx context.Context) error {
+	if _, err := e.client.Models.Get(ctx, e.model, option.WithMaxRetries(0)); err != nil {
+		return fmt.Errorf("embedding model %s unavailable: %w", e.model, err)
+	}
+	return nil
+}
+
+func (e *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	params := openai.EmbeddingNewParams{
+		Model: e.model,
 		Input: openai.EmbeddingNewParamsInputUnion{
 			OfArrayOfStrings: texts,
 		},
-	})
+	}
+	if e.dimensions > 0 {
+		params.Dimensions = openai.Int(e.dimensions)
+	}
+
+	res, err := e.client.Embeddings.New(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("embedding failed: %w", err)
 	}
 
-	var result [][]float32
+	result := make([][]float32, len(texts))
 	for _, item := range res.Data {
+		if item.Index < 0 || int(item.Index) >= len(result) {
+			return nil, fmt.Errorf("embedding failed: unexpected index %d", item.Index)
+		}
 		// Convert []float64 to []float32
 		embedding := make([]float32, len(item.Embedding))
 		for i, val := range item.Embedding {
 			embedding[i] = float32(val)
 		}
-		result = append(result, embedding)
+		result[item.Index] = embedding
 	}
 
 	return result, nil
@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"context"
+)
+
+// HealthChecker is implemented by providers that can check their backend
+// is reachable without spending tokens. Providers that run in process
+// don't implement it and are always healthy once built.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// CheckChatModel builds the configured chat provider and checks its
+// backend.
+func CheckChatModel(ctx context.Context) error {
+	chat, err := DefaultChatModel()
+	if err != nil {
+		return err
+	}
+	return healthCheck(ctx, chat)
+}
+
+// CheckEmbedder builds the configured embedding provider and checks its
+// backend.
+func CheckEmbedder(ctx context.Context) error {
+	embedder, err := DefaultEmbedder()
+	if err != nil {
+		return err
+	}
+	return healthCheck(ctx, embedder)
+}
+
+func healthCheck(ctx context.Context, provider any) error {
+	if hc, ok := provider.(HealthChecker); ok {
+		return hc.HealthCheck(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RewriteQuery turns a follow-up question into a standalone search query
+// using the conversation so far, e.g. "what about the second one?" becomes
+// "second option for the team offsite venue". Without history the question
+// is returned unchanged.
+func RewriteQuery(ctx context.Context, model string, history []ChatMessage, question string) (string, error) {
+	if len(history) == 0 {
+		return question, nil
+	}
+
+	chat, err := DefaultChatModel()
+	if err != nil {
+		return "", err
+	}
+
+	var transcript strings.Builder
+	for _, msg := range history {
+		transcript.WriteString(fmt.Sprintf("%s: %s\n", msg.Role, msg.Content))
+	}
+
+	prompt := fmt.Sprintf(`Rewrite the user's latest question as a single standalone search query that can be understood without the conversation. Resolve pronouns and references using the conversation. Keep the user's wording where possible. Reply with the query only, no quotes or explanation.
+
+Conversation:
+%s
+Question:
+%s
+
+Standalone query:`, transcript.String(), question)
+
+	rewritten, err := chat.Complete(ctx, ChatRequest{
+		Model: model,
+		Messages: []ChatMessage{
+			{Role: RoleUser, Content: prompt},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("query rewrite failed: %w", err)
+	}
+
+	rewritten = strings.Trim(strings.TrimSpace(rewritten), `"'`)
+	if rewritten == "" {
+		return question, nil
+	}
+	return rewritten, nil
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// Conversation is a multi-turn chat with the user's notes. Messages are
+// omitted when conversations are listed.
+type Conversation struct {
+	ID        string                `bson:"_id" json:"id"`
+	UserID    string                `bson:"user_id" json:"user_id"`
+	Title     string                `bson:"title" json:"title"`
+	Messages  []ConversationMessage `bson:"messages" json:"messages,omitempty"`
+	CreatedAt time.Time             `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time             `bson:"updated_at" json:"updated_at"`
+}
+
+// ConversationMessage is one turn. Assistant turns record the standalone
+// query used for retrieval and the notes the answer drew on.
+type ConversationMessage struct {
+	Role      string    `bson:"role" json:"role"`
+	Content   string    `bson:"content" json:"content"`
+	Query     string    `bson:"query,omitempty" json:"query,omitempty"`
+	Model     string    `bson:"model,omitempty" json:"model,omitempty"`
+	NoteIDs   []string  `bson:"note_ids,omitempty" json:"note_ids,omitempty"`
+	Citations []string  `bson:"citations,omitempty" json:"citations,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+type CreateConversationRequest struct {
+	Title string `json:"title"`
+}
+
+type ConversationMessageRequest struct {
+	Question string `json:"question"`
+	Model    string `json:"model"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// maxFilterNoteIDs caps how many note IDs a filter may list.
+const maxFilterNoteIDs = 100
+
+// NoteFilter narrows a query down to some of a user's notes. Every set
+// field must match: the note is in the notebook, carries all the tags, is
+// one of the listed IDs and falls in the date ranges. Ranges include their
+// "after" bound and exclude their "before" bound.
+type NoteFilter struct {
+	NotebookID     string     `json:"notebook_id,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	NoteIDs        []string   `json:"note_ids,omitempty"`
+	CreatedAfter   *time.Time `json:"created_after,omitempty"`
+	CreatedBefore  *time.Time `json:"created_before,omitempty"`
+	ModifiedAfter  *time.Time `json:"modified_after,omitempty"`
+	ModifiedBefore *time.Time `json:"modified_before,omitempty"`
+}
+
+// Validate rejects filters that can never match or are too large.
+func (f NoteFilter) Validate() error {
+	if len(f.NoteIDs) > maxFilterNoteIDs {
+		return fmt.Errorf("note_ids may list at most %d notes", maxFilterNoteIDs)
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		return fmt.Errorf("created_after must be before created_before")
+	}
+	if f.ModifiedAfter != nil && f.ModifiedBefore != nil && !f.ModifiedAfter.Before(*f.ModifiedBefore) {
+		return fmt.Errorf("modified_after must be before modified_before")
+	}
+	return nil
+}
+
+// Matches reports whether note passes the filter, for stores that filter in
+// memory rather than with db.NoteQuery.
+func (f NoteFilter) Matches(note Note) bool {
+	if f.NotebookID != "" && note.NotebookID != f.NotebookID {
+		return false
+	}
+	for _, tag := range NormalizeTags(f.Tags) {
+		if !slices.Contains(note.Tags, tag) {
+			return false
+		}
+	}
+	if len(f.NoteIDs) > 0 && !slices.Contains(f.NoteIDs, note.ID) {
+		return false
+	}
+	return inRange(note.CreatedAt, f.CreatedAfter, f.CreatedBefore) &&
+		inRange(note.ModifiedAt, f.ModifiedAfter, f.ModifiedBefore)
+}
+
+func inRange(t time.Time, after, before *time.Time) bool {
+	return (after == nil || !t.Before(*after)) && (before == nil || t.Before(*before))
+}
@@ -1,25 +1,97 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
+// Index statuses report whether a note's current content is searchable.
+const (
+	IndexStatusPending = "pending"
+	IndexStatusIndexed = "indexed"
+	IndexStatusFailed  = "failed"
+)
+
 type Note struct {
-	ID         string    `bson:"_id" json:"id"`
-	Title      string    `bson:"title" json:"title"`
-	Content    string    `bson:"content" json:"content"`
-	Embeddings []float32 `bson:"embeddings" json:"embeddings"`
-	UserID     string    `bson:"user_id" json:"user_id"`
-	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
-	ModifiedAt time.Time `bson:"modified_at" json:"modified_at"`
+	ID          string     `bson:"_id" json:"id"`
+	Title       string     `bson:"title" json:"title"`
+	Content     string     `bson:"content" json:"content"`
+	UserID      string     `bson:"user_id" json:"user_id"`
+	NotebookID  string     `bson:"notebook_id,omitempty" json:"notebook_id,omitempty"`
+	Tags        []string   `bson:"tags,omitempty" json:"tags,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	ModifiedAt  time.Time  `bson:"modified_at" json:"modified_at"`
+	IndexStatus string     `bson:"index_status,omitempty" json:"index_status,omitempty"`
+	IndexError  string     `bson:"index_error,omitempty" json:"index_error,omitempty"`
+	IndexedAt   *time.Time `bson:"indexed_at,omitempty" json:"indexed_at,omitempty"`
+	// DeletedAt is set while the note is in the trash.
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	// Version is bumped on every edit. Notes saved before versioning have
+	// none and count as version 0.
+	Version int64 `bson:"version" json:"version"`
 }
 
 type CreateNoteRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title      string   `json:"title"`
+	Content    string   `json:"content"`
+	NotebookID string   `json:"notebook_id"`
+	Tags       []string `json:"tags"`
 }
 
+// UpdateNoteRequest replaces a note's title and content. Tags and
+// NotebookID are left alone when omitted; an empty notebook ID moves the
+// note out of its notebook.
 type UpdateNoteRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title      string   `json:"title"`
+	Content    string   `json:"content"`
+	NotebookID *string  `json:"notebook_id"`
+	Tags       []string `json:"tags"`
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty ones and
+// duplicates while keeping their order.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// NoteIndexStatus is the response of GET /notes/{id}/index-status.
+type NoteIndexStatus struct {
+	NoteID      string     `json:"note_id"`
+	IndexStatus string     `json:"index_status"`
+	IndexError  string     `json:"index_error,omitempty"`
+	IndexedAt   *time.Time `json:"indexed_at,omitempty"`
+	ModifiedAt  time.Time  `json:"modified_at"`
+}
+
+// NoteListQuery selects a page of a user's notes. Sort is one of the
+// NoteSort constants; Cursor is the NextCursor of the previous page.
+type NoteListQuery struct {
+	Filter NoteFilter
+	Sort   string
+	Desc   bool
+	Limit  int
+	Cursor string
+}
+
+// Sort keys for listing notes.
+const (
+	NoteSortCreated  = "created"
+	NoteSortModified = "modified"
+	NoteSortTitle    = "title"
+)
+
+// NotePage is the response of GET /notes. NextCursor is empty on the last
+// page.
+type NotePage struct {
+	Notes      []NoteResponse `json:"notes"`
+	NextCursor string         `json:"next_cursor,omitempty"`
 }
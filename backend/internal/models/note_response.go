@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// NoteResponse is a note as returned by the API. It is kept apart from the
+// stored Note so storage-only fields don't leak into responses. Embedding
+// is only filled in when the client asks for it with ?include=embedding.
+type NoteResponse struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Content     string     `json:"content"`
+	UserID      string     `json:"user_id"`
+	NotebookID  string     `json:"notebook_id,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ModifiedAt  time.Time  `json:"modified_at"`
+	IndexStatus string     `json:"index_status,omitempty"`
+	IndexError  string     `json:"index_error,omitempty"`
+	IndexedAt   *time.Time `json:"indexed_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	Version     int64      `json:"version"`
+	Embedding   []float32  `json:"embedding,omitempty"`
+}
+
+func NewNoteResponse(note Note) NoteResponse {
+	return NoteResponse{
+		ID:          note.ID,
+		Title:       note.Title,
+		Content:     note.Content,
+		UserID:      note.UserID,
+		NotebookID:  note.NotebookID,
+		Tags:        note.Tags,
+		CreatedAt:   note.CreatedAt,
+		ModifiedAt:  note.ModifiedAt,
+		IndexStatus: note.IndexStatus,
+		IndexError:  note.IndexError,
+		IndexedAt:   note.IndexedAt,
+		DeletedAt:   note.DeletedAt,
+		Version:     note.Version,
+	}
+}
+
+func NewNoteResponses(notes []Note) []NoteResponse {
+	out := make([]NoteResponse, len(notes))
+	for i, note := range notes {
+		out[i] = NewNoteResponse(note)
+	}
+	return out
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// Notebook groups a user's notes. A note belongs to at most one notebook.
+type Notebook struct {
+	ID        string    `bson:"_id" json:"id"`
+	UserID    string    `bson:"user_id" json:"user_id"`
+	Name      string    `bson:"name" json:"name"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+type NotebookRequest struct {
+	Name string `json:"name"`
+}
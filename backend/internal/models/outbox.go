@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// Index operations recorded in the outbox.
+const (
+	IndexOpUpsert = "upsert"
+	IndexOpDelete = "delete"
+)
+
+// OutboxEntry is a pending change to a note's vectors. It is written in the
+// same transaction as the note itself and removed once the vector store
+// has caught up.
+type OutboxEntry struct {
+	ID            string    `bson:"_id" json:"id"`
+	NoteID        string    `bson:"note_id" json:"note_id"`
+	UserID        string    `bson:"user_id" json:"user_id"`
+	Op            string    `bson:"op" json:"op"`
+	Attempts      int       `bson:"attempts" json:"attempts"`
+	LastError     string    `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	Dead          bool      `bson:"dead" json:"dead"`
+	NextAttemptAt time.Time `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil   time.Time `bson:"locked_until" json:"locked_until"`
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"note-llm/internal/textdiff"
+)
+
+// NoteRevision is a saved earlier version of a note. A revision is
+// written every time a note is changed, holding the state it had before
+// the change. Number counts up from 1 per note.
+type NoteRevision struct {
+	ID         string    `bson:"_id" json:"id"`
+	NoteID     string    `bson:"note_id" json:"note_id"`
+	UserID     string    `bson:"user_id" json:"user_id"`
+	Number     int       `bson:"number" json:"number"`
+	Title      string    `bson:"title" json:"title"`
+	Content    string    `bson:"content,omitempty" json:"content,omitempty"`
+	NotebookID string    `bson:"notebook_id,omitempty" json:"notebook_id,omitempty"`
+	Tags       []string  `bson:"tags,omitempty" json:"tags,omitempty"`
+	ModifiedAt time.Time `bson:"modified_at" json:"modified_at"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+}
+
+// NoteDiff is the response of GET /notes/{id}/revisions/diff. From and To
+// are revision numbers, or "current" for the note as it is now.
+type NoteDiff struct {
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	FromTitle string          `json:"from_title"`
+	ToTitle   string          `json:"to_title"`
+	Lines     []textdiff.Line `json:"lines"`
+}
@@ -0,0 +1,128 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"note-llm/internal/chunking"
+	"note-llm/internal/db"
+	"note-llm/internal/indexing"
+	"note-llm/internal/models"
+	"note-llm/internal/vectorstore"
+
+	"github.com/spf13/viper"
+)
+
+// Config controls how outbox entries are processed and retried.
+type Config struct {
+	Workers      int
+	QueueSize    int
+	PollInterval time.Duration
+	Lease        time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	// Chunking is how notes are split before they are embedded.
+	Chunking chunking.Options
+}
+
+// ConfigFromViper reads the OUTBOX_* and CHUNK_* settings.
+func ConfigFromViper() Config {
+	viper.SetDefault("OUTBOX_WORKERS", 4)
+	viper.SetDefault("OUTBOX_QUEUE_SIZE", 100)
+	viper.SetDefault("OUTBOX_POLL_INTERVAL", 2*time.Second)
+	viper.SetDefault("OUTBOX_LEASE", time.Minute)
+	viper.SetDefault("OUTBOX_MAX_ATTEMPTS", 10)
+	viper.SetDefault("OUTBOX_BASE_BACKOFF", 2*time.Second)
+	viper.SetDefault("OUTBOX_MAX_BACKOFF", 10*time.Minute)
+
+	return Config{
+		Workers:      viper.GetInt("OUTBOX_WORKERS"),
+		QueueSize:    viper.GetInt("OUTBOX_QUEUE_SIZE"),
+		PollInterval: viper.GetDuration("OUTBOX_POLL_INTERVAL"),
+		Lease:        viper.GetDuration("OUTBOX_LEASE"),
+		MaxAttempts:  viper.GetInt("OUTBOX_MAX_ATTEMPTS"),
+		BaseBackoff:  viper.GetDuration("OUTBOX_BASE_BACKOFF"),
+		MaxBackoff:   viper.GetDuration("OUTBOX_MAX_BACKOFF"),
+		Chunking:     chunking.DefaultOptions(),
+	}
+}
+
+// apply syncs the entry's note and then completes or reschedules it. It
+// holds the note's lock meanwhile: two workers indexing different versions
+// of a note at once could otherwise finish in the wrong order and leave
+// the older vectors in the store. An entry whose note is locked goes back
+// to the queue for the poller.
+func apply(ctx context.Context, cfg Config, entry *models.OutboxEntry) {
+	locked, err := db.LockOutboxNote(ctx, entry.NoteID, entry.ID, cfg.Lease)
+	if err != nil {
+		fmt.Printf("Outbox lock error for note %s: %v\n", entry.NoteID, err)
+		return
+	}
+	if !locked {
+		if err := db.ReleaseOutboxEntry(ctx, entry.ID, time.Now().Add(cfg.PollInterval)); err != nil {
+			fmt.Printf("Outbox release error for %s: %v\n", entry.ID, err)
+		}
+		return
+	}
+	defer func() {
+		if err := db.UnlockOutboxNote(ctx, entry.NoteID, entry.ID); err != nil && ctx.Err() == nil {
+			fmt.Printf("Outbox unlock error for note %s: %v\n", entry.NoteID, err)
+		}
+	}()
+
+	_, err = syncNote(ctx, cfg, entry)
+	if err == nil {
+		if err := db.CompleteOutboxEntry(ctx, entry.ID); err != nil {
+			fmt.Printf("Outbox complete error for %s: %v\n", entry.ID, err)
+		}
+		return
+	}
+
+	dead := cfg.MaxAttempts > 0 && entry.Attempts >= cfg.MaxAttempts
+	next := time.Now().Add(backoff(cfg, entry.Attempts))
+	fmt.Printf("Outbox %s of note %s failed (attempt %d, giving up: %v): %v\n", entry.Op, entry.NoteID, entry.Attempts, dead, err)
+	if err := db.RescheduleOutboxEntry(ctx, entry.ID, next, err.Error(), dead); err != nil {
+		fmt.Printf("Outbox reschedule error for %s: %v\n", entry.ID, err)
+	}
+}
+
+// syncNote makes the vector store match the note's current state in
+// MongoDB rather than replaying the recorded operation blindly, so entries
+// for the same note can be applied in any order and more than once.
+func syncNote(ctx context.Context, cfg Config, entry *models.OutboxEntry) (*models.Note, error) {
+	store, err := vectorstore.Default()
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := db.FetchNoteByID(ctx, entry.NoteID, entry.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load note: %w", err)
+	}
+	if note == nil {
+		return nil, store.Delete(ctx, entry.NoteID)
+	}
+
+	indexErr := indexing.IndexNote(ctx, store, note, cfg.Chunking)
+	if err := db.SaveNoteIndexState(ctx, *note); err != nil {
+		return note, fmt.Errorf("failed to save index status: %w", err)
+	}
+	return note, indexErr
+}
+
+// backoff doubles the delay per attempt, capped at MaxBackoff, with up to
+// 20% jitter so retries of a burst of failures spread out.
+func backoff(cfg Config, attempts int) time.Duration {
+	delay := cfg.BaseBackoff
+	for i := 1; i < attempts && delay < cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, cfg.MaxBackoff)
+	if delay <= 0 {
+		return 0
+	}
+	return delay + rand.N(delay/5+1)
+}
@@ -0,0 +1,29 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	cfg := Config{BaseBackoff: 2 * time.Second, MaxBackoff: 10 * time.Second}
+	for attempts, want := range map[int]time.Duration{
+		0:  2 * time.Second,
+		1:  2 * time.Second,
+		2:  4 * time.Second,
+		3:  8 * time.Second,
+		4:  10 * time.Second,
+		50: 10 * time.Second,
+	} {
+		for range 20 {
+			// Up to 20% jitter on top.
+			if got := backoff(cfg, attempts); got < want || got > want+want/5 {
+				t.Fatalf("backoff after %d attempts = %v, want %v plus up to 20%%", attempts, got, want)
+			}
+		}
+	}
+
+	if got := backoff(Config{}, 3); got != 0 {
+		t.Errorf("backoff without a base = %v, want 0", got)
+	}
+}
@@ -0,0 +1,132 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"note-llm/internal/db"
+	"note-llm/internal/models"
+)
+
+// job is a unit of work for the pool. Entries found by the poller are
+// already claimed; entries handed over by Notify still have to be.
+type job struct {
+	entry   *models.OutboxEntry
+	claimed bool
+}
+
+// Pool applies outbox entries with a fixed number of workers fed by a
+// bounded queue. Request handlers push new entries through Notify; a poller
+// picks up everything else (overflow, retries, leftovers from a restart).
+type Pool struct {
+	cfg   Config
+	queue chan job
+	wg    sync.WaitGroup
+}
+
+var (
+	defaultPoolMu sync.RWMutex
+	defaultPool   *Pool
+)
+
+// Start launches the workers and the poller and makes the pool the target
+// of Notify. They stop when ctx is cancelled; call Wait to let in-flight
+// work finish.
+func Start(ctx context.Context, cfg Config) *Pool {
+	p := &Pool{
+		cfg:   cfg,
+		queue: make(chan job, max(cfg.QueueSize, 1)),
+	}
+
+	for i := 0; i < max(cfg.Workers, 1); i++ {
+		p.wg.Add(1)
+		go p.work(ctx)
+	}
+	p.wg.Add(1)
+	go p.poll(ctx)
+
+	defaultPoolMu.Lock()
+	defaultPool = p
+	defaultPoolMu.Unlock()
+
+	return p
+}
+
+// Wait blocks until every worker and the poller have returned.
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+// Notify queues a freshly written entry for immediate processing without
+// blocking. When the queue is full, or no pool is running, the entry is
+// left for the poller.
+func Notify(entry *models.OutboxEntry) {
+	defaultPoolMu.RLock()
+	p := defaultPool
+	defaultPoolMu.RUnlock()
+	if p == nil || entry == nil {
+		return
+	}
+
+	select {
+	case p.queue <- job{entry: entry}:
+	default:
+	}
+}
+
+func (p *Pool) work(ctx context.Context) {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-p.queue:
+			p.run(ctx, j)
+		}
+	}
+}
+
+func (p *Pool) run(ctx context.Context, j job) {
+	entry := j.entry
+	if !j.claimed {
+		claimed, err := db.ClaimOutboxEntryByID(ctx, entry.ID, p.cfg.Lease)
+		if err != nil {
+			fmt.Printf("Outbox claim error for %s: %v\n", entry.ID, err)
+			return
+		}
+		// Already done or held by someone else.
+		if claimed == nil {
+			return
+		}
+		entry = claimed
+	}
+	apply(ctx, p.cfg, entry)
+}
+
+// poll claims due entries and feeds them to the workers, blocking while the
+// queue is full so that claimed entries are never dropped.
+func (p *Pool) poll(ctx context.Context) {
+	defer p.wg.Done()
+	for {
+		entry, err := db.ClaimOutboxEntry(ctx, p.cfg.Lease)
+		if err != nil && ctx.Err() == nil {
+			fmt.Printf("Outbox claim error: %v\n", err)
+		}
+		if entry != nil {
+			select {
+			case p.queue <- job{entry: entry, claimed: true}:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.cfg.PollInterval):
+		}
+	}
+}
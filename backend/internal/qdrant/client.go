@@ -1,21 +1,76 @@
 package qdrant
 
 import (
+	"context"
+	"errors"
+	"sync"
+
 	"github.com/qdrant/go-client/qdrant"
 	"github.com/spf13/viper"
 )
 
-func GetQdrantClient() *qdrant.Client {
-	apiKey := viper.GetString("QDRANT_API")
-	host := viper.GetString("QDRANT_HOST")
-	client, err := qdrant.NewClient(&qdrant.Config{
-		Host:   host,
+// ErrClosed is returned by Connect once Close has been called.
+var ErrClosed = errors.New("qdrant client is closed")
+
+var (
+	clientMu sync.Mutex
+	client   *qdrant.Client
+	closed   bool
+)
+
+// Connect opens the gRPC client that GetQdrantClient shares. Calling it
+// again returns the open client.
+func Connect() (*qdrant.Client, error) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	if closed {
+		return nil, ErrClosed
+	}
+	if client != nil {
+		return client, nil
+	}
+
+	c, err := qdrant.NewClient(&qdrant.Config{
+		Host:   viper.GetString("QDRANT_HOST"),
 		Port:   6334,
-		APIKey: apiKey,
+		APIKey: viper.GetString("QDRANT_API"),
 		UseTLS: true,
 	})
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	client = c
+	return client, nil
+}
+
+// HealthCheck asks the server whether it is up.
+func HealthCheck(ctx context.Context) error {
+	c, err := Connect()
+	if err != nil {
+		return err
 	}
-	return client
+	_, err = c.HealthCheck(ctx)
+	return err
+}
+
+// Close closes the shared client for shutdown. It is not reopened: later
+// calls to Connect fail with ErrClosed.
+func Close() error {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	closed = true
+	if client == nil {
+		return nil
+	}
+	err := client.Close()
+	client = nil
+	return err
+}
+
+// GetQdrantClient returns the shared client, connecting on first use. It
+// fails with ErrClosed after Close.
+func GetQdrantClient() (*qdrant.Client, error) {
+	return Connect()
 }
@@ -3,41 +3,177 @@ package rag
 import (
 	"context"
 	"fmt"
+	"time"
+	"unicode/utf8"
 
-	"note-llm/internal/db"
 	"note-llm/internal/llm"
+	"note-llm/internal/models"
+	"note-llm/internal/rerank"
 	"note-llm/internal/search"
 )
 
-// AnswerFromUserNotes performs the full RAG flow:
-// embed → vector search → fetch from MongoDB → pass to LLM
-func AnswerFromUserNotes(ctx context.Context, userID, question string) (string, error) {
-	// Step 1: Get relevant note IDs from Qdrant
-	noteIDs, err := search.SearchRelevantNotes(userID, question)
+// noNotesAnswer is returned without calling the LLM when retrieval comes
+// back empty.
+const noNotesAnswer = "No relevant notes found."
+
+// Request is a question to answer from a user's notes.
+type Request struct {
+	UserID   string
+	Question string
+	// Model selects the chat model; empty means the configured default.
+	Model string
+	// History holds earlier turns of a conversation, oldest first.
+	History []llm.ChatMessage
+	// Search tunes retrieval; zero values use the configured defaults.
+	Search search.Options
+	// Rerank turns the reranking stage on or off; nil uses RERANK_ENABLED.
+	Rerank *bool
+}
+
+// Answer is the result of a RAG question. Sources lists every excerpt the
+// model was given; Citations the source IDs the answer actually cites.
+// Query is the standalone search query when a follow-up was rewritten.
+// Context reports excerpts trimmed or dropped to fit the token budget and
+// Rerank the reranking stage when it ran.
+type Answer struct {
+	Answer    string         `json:"answer"`
+	Model     string         `json:"model"`
+	Query     string         `json:"query,omitempty"`
+	NoteIDs   []string       `json:"note_ids"`
+	Sources   []Source       `json:"sources"`
+	Citations []string       `json:"citations"`
+	Context   *ContextReport `json:"context,omitempty"`
+	Rerank    *RerankReport  `json:"rerank,omitempty"`
+	Timings   Timings        `json:"timings"`
+}
+
+// Timings are the durations of the answer stages in milliseconds.
+type Timings struct {
+	RetrievalMs  int64 `json:"retrieval_ms"`
+	RerankMs     int64 `json:"rerank_ms,omitempty"`
+	GenerationMs int64 `json:"generation_ms,omitempty"`
+}
+
+// AnswerFromUserNotes performs the full RAG flow over the notes in stores:
+// (rewrite follow-up) → embed → vector search → fetch notes → rerank → pass to LLM.
+func AnswerFromUserNotes(ctx context.Context, stores search.Stores, req Request) (*Answer, error) {
+	return answer(ctx, stores, req, nil)
+}
+
+// StreamAnswerFromUserNotes is AnswerFromUserNotes, passing the answer to
+// onDelta piece by piece as the model generates it.
+func StreamAnswerFromUserNotes(ctx context.Context, stores search.Stores, req Request, onDelta func(delta string) error) (*Answer, error) {
+	return answer(ctx, stores, req, onDelta)
+}
+
+func answer(ctx context.Context, stores search.Stores, req Request, onDelta func(delta string) error) (*Answer, error) {
+	userID, model := req.UserID, req.Model
+
+	// Step 0: Turn a follow-up into a standalone query for retrieval
+	query, err := llm.RewriteQuery(ctx, model, req.History, req.Question)
 	if err != nil {
-		return "", fmt.Errorf("search failed: %w", err)
+		return nil, err
+	}
+
+	// Step 1: Get relevant note chunks from the vector store, over-fetching
+	// when a reranker will pick the best of them
+	opts := req.Search.WithDefaults()
+	keep := opts.Limit
+	reranking := rerank.Enabled()
+	if req.Rerank != nil {
+		reranking = *req.Rerank
 	}
-	if len(noteIDs) == 0 {
-		return "No relevant notes found.", nil
+	if reranking {
+		opts.Limit = min(keep*rerank.CandidateFactor(), search.MaxLimit)
 	}
 
-	// Step 2: Fetch full note content from MongoDB
-	notes, err := db.FetchNotesByIDs(ctx, noteIDs, userID)
+	var timings Timings
+	started := time.Now()
+	hits, err := search.SearchRelevantChunks(ctx, stores.Vectors, userID, query, opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch notes from DB: %w", err)
+		return nil, fmt.Errorf("search failed: %w", err)
 	}
 
-	// Step 3: Extract note content
-	var noteTexts []string
-	for _, note := range notes {
-		noteTexts = append(noteTexts, fmt.Sprintf("%s\n\n%s", note.Title, note.Content))
+	// Step 2: Fetch full note content, applying the filter again in case
+	// the indexed payload is out of date
+	var notes []models.Note
+	if len(hits) > 0 {
+		notes, err = stores.Notes.FindMatching(ctx, search.NoteIDs(hits), userID, opts.NoteFilter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch notes from DB: %w", err)
+		}
 	}
 
-	// Step 4: Ask the LLM
-	answer, err := llm.Summarize(question, noteTexts)
+	timings.RetrievalMs = time.Since(started).Milliseconds()
+
+	// Step 3: Cut the matching chunks out of the notes
+	excerpts, sources := buildSources(hits, notes)
+
+	// Step 4: Rerank, then fit the best excerpts into the model's context
+	// budget
+	var reranked *RerankReport
+	if reranking && len(excerpts) > 0 {
+		started = time.Now()
+		excerpts, sources, reranked = rerankSources(ctx, query, excerpts, sources, keep)
+		timings.RerankMs = time.Since(started).Milliseconds()
+	}
+	var packed *ContextReport
+	if len(excerpts) > 0 {
+		excerpts, sources, packed = packContext(excerpts, sources, llm.ContextBudget(model))
+	}
+	result := &Answer{
+		Model:     model,
+		NoteIDs:   sourceNoteIDs(sources),
+		Sources:   sources,
+		Citations: []string{},
+		Context:   packed,
+		Rerank:    reranked,
+	}
+	if query != req.Question {
+		result.Query = query
+	}
+	if len(excerpts) == 0 {
+		result.Answer = noNotesAnswer
+		result.Timings = timings
+		if onDelta != nil {
+			if err := onDelta(result.Answer); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+
+	// Step 5: Ask the LLM
+	var text string
+	started = time.Now()
+	if onDelta != nil {
+		text, err = llm.SummarizeStream(ctx, model, req.Question, req.History, excerpts, onDelta)
+	} else {
+		text, err = llm.Summarize(ctx, model, req.Question, req.History, excerpts)
+	}
 	if err != nil {
-		return "", fmt.Errorf("LLM call failed: %w", err)
+		return nil, fmt.Errorf("LLM call failed: %w", err)
 	}
+	timings.GenerationMs = time.Since(started).Milliseconds()
+	result.Timings = timings
 
-	return answer, nil
+	// Step 6: Keep only citations of sources the model was actually given
+	result.Answer, result.Citations = validateCitations(text, sources)
+
+	return result, nil
+}
+
+// ChunkText returns the part of the note content a hit refers to, or the
+// whole content when the offsets are missing or out of date. Offsets from
+// another version of the note can still be in range but cut through a
+// character, so they are only trusted for the version they were indexed
+// from and when both fall on character boundaries.
+func ChunkText(note models.Note, hit search.ChunkHit) string {
+	if hit.NoteVersion != note.Version || hit.End <= hit.Start || hit.End > len(note.Content) {
+		return note.Content
+	}
+	if !utf8.RuneStart(note.Content[hit.Start]) || (hit.End < len(note.Content) && !utf8.RuneStart(note.Content[hit.End])) {
+		return note.Content
+	}
+	return note.Content[hit.Start:hit.End]
 }
@@ -0,0 +1,31 @@
+package rag
+
+import (
+	"testing"
+
+	"note-llm/internal/models"
+	"note-llm/internal/search"
+)
+
+func TestChunkText(t *testing.T) {
+	note := models.Note{Content: "Crème brûlée needs a torch.", Version: 3}
+	start := len("Crème ")
+	end := len("Crème brûlée")
+
+	for name, tc := range map[string]struct {
+		hit  search.ChunkHit
+		want string
+	}{
+		"exact":           {search.ChunkHit{NoteVersion: 3, Start: start, End: end}, "brûlée"},
+		"other version":   {search.ChunkHit{NoteVersion: 2, Start: start, End: end}, note.Content},
+		"legacy point":    {search.ChunkHit{NoteVersion: 3}, note.Content},
+		"out of range":    {search.ChunkHit{NoteVersion: 3, Start: start, End: len(note.Content) + 1}, note.Content},
+		"splits start":    {search.ChunkHit{NoteVersion: 3, Start: len("Crè") - 1, End: end}, note.Content},
+		"splits end":      {search.ChunkHit{NoteVersion: 3, Start: start, End: len("Crème brû") - 1}, note.Content},
+		"up to last byte": {search.ChunkHit{NoteVersion: 3, Start: start, End: len(note.Content)}, "brûlée needs a torch."},
+	} {
+		if got := ChunkText(note, tc.hit); got != tc.want {
+			t.Errorf("%s: ChunkText = %q, want %q", name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package rag
+
+import (
+	"fmt"
+
+	"note-llm/internal/llm"
+	"note-llm/internal/tokenizer"
+)
+
+// minExcerptTokens is the smallest trimmed excerpt worth sending; when less
+// room than this is left, an overflowing excerpt is dropped instead.
+const minExcerptTokens = 50
+
+// ContextReport tells how the retrieved excerpts were fitted into the
+// model's context budget.
+type ContextReport struct {
+	Budget  int              `json:"budget"`
+	Tokens  int              `json:"tokens"`
+	Trimmed []ContextExcerpt `json:"trimmed"`
+	Dropped []ContextExcerpt `json:"dropped"`
+}
+
+// ContextExcerpt is an excerpt that did not fit whole. SourceID is empty
+// for dropped excerpts, which the model never saw.
+type ContextExcerpt struct {
+	SourceID   string `json:"source_id,omitempty"`
+	NoteID     string `json:"note_id"`
+	ChunkIndex int    `json:"chunk_index"`
+	Title      string `json:"title"`
+	Tokens     int    `json:"tokens"`
+	KeptTokens int    `json:"kept_tokens"`
+}
+
+// packContext keeps excerpts in rank order while they fit in budget
+// tokens. An excerpt that overflows is cut down to the room left, or
+// dropped when that is under minExcerptTokens; later, smaller excerpts may
+// still fit. Kept excerpts are relabelled S1, S2, ... so the IDs the model
+// sees stay contiguous.
+func packContext(excerpts []llm.Excerpt, sources []Source, budget int) ([]llm.Excerpt, []Source, *ContextReport) {
+	report := &ContextReport{Budget: budget, Trimmed: []ContextExcerpt{}, Dropped: []ContextExcerpt{}}
+
+	var packed []llm.Excerpt
+	kept := []Source{}
+	for i, excerpt := range excerpts {
+		src := sources[i]
+		tokens := llm.ExcerptTokens(excerpt)
+		remaining := budget - report.Tokens
+
+		id := fmt.Sprintf("S%d", len(kept)+1)
+		excerpt.ID, src.ID = id, id
+
+		if tokens <= remaining {
+			packed = append(packed, excerpt)
+			kept = append(kept, src)
+			report.Tokens += tokens
+			continue
+		}
+
+		// Room for the text once the ID and title line are paid for.
+		header := llm.ExcerptTokens(llm.Excerpt{ID: id, Title: excerpt.Title})
+		room := remaining - header
+		if room < minExcerptTokens {
+			report.Dropped = append(report.Dropped, ContextExcerpt{
+				NoteID:     src.NoteID,
+				ChunkIndex: src.ChunkIndex,
+				Title:      src.Title,
+				Tokens:     tokens,
+			})
+			continue
+		}
+
+		excerpt.Text = tokenizer.Truncate(excerpt.Text, room-1) + "…"
+		used := llm.ExcerptTokens(excerpt)
+		packed = append(packed, excerpt)
+		kept = append(kept, src)
+		report.Tokens += used
+		report.Trimmed = append(report.Trimmed, ContextExcerpt{
+			SourceID:   id,
+			NoteID:     src.NoteID,
+			ChunkIndex: src.ChunkIndex,
+			Title:      src.Title,
+			Tokens:     tokens,
+			KeptTokens: used,
+		})
+	}
+
+	return packed, kept, report
+}
@@ -0,0 +1,42 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+
+	"note-llm/internal/llm"
+)
+
+func TestPackContext(t *testing.T) {
+	long := strings.Repeat("word ", 300)
+	excerpts := []llm.Excerpt{
+		{ID: "S1", Title: "short", Text: "Rye needs more water."},
+		{ID: "S2", Title: "long", Text: long},
+		{ID: "S3", Title: "tiny", Text: "Salt."},
+	}
+	sources := []Source{
+		{ID: "S1", NoteID: "n1"},
+		{ID: "S2", NoteID: "n2"},
+		{ID: "S3", NoteID: "n3"},
+	}
+	budget := llm.ExcerptTokens(excerpts[0]) + 100
+
+	packed, kept, report := packContext(excerpts, sources, budget)
+	if len(packed) != 2 || len(kept) != 2 {
+		t.Fatalf("kept %d excerpts, want 2", len(packed))
+	}
+	if len(report.Trimmed) != 1 || report.Trimmed[0].NoteID != "n2" {
+		t.Fatalf("trimmed = %+v, want n2", report.Trimmed)
+	}
+	if !strings.HasSuffix(packed[1].Text, "…") || report.Tokens > budget {
+		t.Errorf("trimmed excerpt not cut to budget: %d tokens of %d", report.Tokens, budget)
+	}
+	if len(report.Dropped) != 1 || report.Dropped[0].NoteID != "n3" {
+		t.Errorf("dropped = %+v, want n3", report.Dropped)
+	}
+	for i, src := range kept {
+		if src.ID != packed[i].ID {
+			t.Errorf("source %d labelled %s, excerpt %s", i, src.ID, packed[i].ID)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"note-llm/internal/llm"
+	"note-llm/internal/rerank"
+)
+
+// RerankReport describes the reranking stage of an answer. Error is set
+// when the reranker failed and the retrieval order was kept.
+type RerankReport struct {
+	Provider   string `json:"provider"`
+	Candidates int    `json:"candidates"`
+	Kept       int    `json:"kept"`
+	Error      string `json:"error,omitempty"`
+}
+
+// rerankSources reorders the retrieved excerpts with the configured
+// reranker and keeps the best keep of them. A failing reranker doesn't
+// fail the answer; the top of the retrieval order is used instead.
+func rerankSources(ctx context.Context, query string, excerpts []llm.Excerpt, sources []Source, keep int) ([]llm.Excerpt, []Source, *RerankReport) {
+	report := &RerankReport{Provider: rerank.ProviderName(), Candidates: len(excerpts)}
+
+	scores, err := scoreExcerpts(ctx, query, excerpts)
+	if err != nil {
+		fmt.Printf("Rerank error: %v\n", err)
+		report.Error = err.Error()
+		scores = nil
+	}
+
+	order := make([]int, len(excerpts))
+	for i := range order {
+		order[i] = i
+	}
+	if scores != nil {
+		sort.SliceStable(order, func(a, b int) bool {
+			return scores[order[a]] > scores[order[b]]
+		})
+	}
+
+	keep = min(keep, len(order))
+	rankedExcerpts := make([]llm.Excerpt, 0, keep)
+	rankedSources := make([]Source, 0, keep)
+	for _, i := range order[:keep] {
+		src := sources[i]
+		if scores != nil {
+			score := scores[i]
+			src.RerankScore = &score
+		}
+		rankedExcerpts = append(rankedExcerpts, excerpts[i])
+		rankedSources = append(rankedSources, src)
+	}
+	report.Kept = keep
+
+	return rankedExcerpts, rankedSources, report
+}
+
+func scoreExcerpts(ctx context.Context, query string, excerpts []llm.Excerpt) ([]float64, error) {
+	reranker, err := rerank.Default()
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make([]string, len(excerpts))
+	for i, excerpt := range excerpts {
+		documents[i] = excerpt.Title + "\n" + excerpt.Text
+	}
+
+	scores, err := reranker.Rerank(ctx, query, documents)
+	if err != nil {
+		return nil, err
+	}
+	if scores != nil && len(scores) != len(documents) {
+		return nil, fmt.Errorf("reranker returned %d scores for %d documents", len(scores), len(documents))
+	}
+	return scores, nil
+}
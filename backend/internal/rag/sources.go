@@ -0,0 +1,127 @@
+package rag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"note-llm/internal/llm"
+	"note-llm/internal/models"
+	"note-llm/internal/search"
+	"note-llm/internal/tokenizer"
+)
+
+// snippetLength caps the excerpt preview returned with each source.
+const snippetLength = 240
+
+// Source is one note excerpt given to the model, labelled with the ID the
+// model cites it by.
+type Source struct {
+	ID         string  `json:"id"`
+	NoteID     string  `json:"note_id"`
+	ChunkIndex int     `json:"chunk_index"`
+	Title      string  `json:"title"`
+	Score      float32 `json:"score"`
+	// RerankScore is set when a reranker reordered the sources.
+	RerankScore *float64 `json:"rerank_score,omitempty"`
+	Snippet     string   `json:"snippet"`
+}
+
+// buildSources turns ranked hits into labelled excerpts for the prompt and
+// the matching sources for the response. Hits whose note no longer exists
+// are skipped.
+func buildSources(hits []search.ChunkHit, notes []models.Note) ([]llm.Excerpt, []Source) {
+	byID := make(map[string]models.Note, len(notes))
+	for _, note := range notes {
+		byID[note.ID] = note
+	}
+
+	var excerpts []llm.Excerpt
+	sources := []Source{}
+	for _, hit := range hits {
+		note, ok := byID[hit.NoteID]
+		if !ok {
+			continue
+		}
+
+		id := fmt.Sprintf("S%d", len(sources)+1)
+		text := ChunkText(note, hit)
+		excerpts = append(excerpts, llm.Excerpt{ID: id, Title: note.Title, Text: text})
+		sources = append(sources, Source{
+			ID:         id,
+			NoteID:     note.ID,
+			ChunkIndex: hit.ChunkIndex,
+			Title:      note.Title,
+			Score:      hit.Score,
+			Snippet:    snippet(text),
+		})
+	}
+	return excerpts, sources
+}
+
+// sourceNoteIDs returns the distinct note IDs of sources in order.
+func sourceNoteIDs(sources []Source) []string {
+	seen := make(map[string]bool, len(sources))
+	ids := []string{}
+	for _, src := range sources {
+		if !seen[src.NoteID] {
+			seen[src.NoteID] = true
+			ids = append(ids, src.NoteID)
+		}
+	}
+	return ids
+}
+
+// citationGroup matches bracketed citations such as [S1] or [S1, S3].
+var citationGroup = regexp.MustCompile(`\s?\[\s*S\d+(?:\s*[,;]\s*S\d+)*\s*\]`)
+
+var citationID = regexp.MustCompile(`S\d+`)
+
+// validateCitations removes citations of IDs that were not in the
+// retrieved set and returns the cleaned answer together with the distinct
+// valid IDs in order of first use.
+func validateCitations(answer string, sources []Source) (string, []string) {
+	known := make(map[string]bool, len(sources))
+	for _, src := range sources {
+		known[src.ID] = true
+	}
+
+	cited := []string{}
+	seen := map[string]bool{}
+	cleaned := citationGroup.ReplaceAllStringFunc(answer, func(group string) string {
+		var valid []string
+		for _, id := range citationID.FindAllString(group, -1) {
+			if !known[id] {
+				continue
+			}
+			valid = append(valid, id)
+			if !seen[id] {
+				seen[id] = true
+				cited = append(cited, id)
+			}
+		}
+		if len(valid) == 0 {
+			return ""
+		}
+		prefix := ""
+		if group[0] != '[' {
+			prefix = group[:1]
+		}
+		return prefix + "[" + strings.Join(valid, ", ") + "]"
+	})
+
+	return cleaned, cited
+}
+
+// snippet shortens text to about snippetLength bytes on a word boundary.
+func snippet(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= snippetLength {
+		return text
+	}
+	cut := strings.LastIndex(text[:snippetLength], " ")
+	if cut <= 0 {
+		cut = tokenizer.RuneBoundary(text, snippetLength)
+	}
+	return text[:cut] + "…"
+}
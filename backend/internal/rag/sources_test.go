@@ -0,0 +1,19 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateCitations(t *testing.T) {
+	sources := []Source{{ID: "S1"}, {ID: "S2"}}
+	answer := "Rye is dense [S2]. It needs water [S1, S7]. Bake hot [S9]."
+
+	cleaned, cited := validateCitations(answer, sources)
+	if want := "Rye is dense [S2]. It needs water [S1]. Bake hot."; cleaned != want {
+		t.Errorf("cleaned = %q, want %q", cleaned, want)
+	}
+	if want := []string{"S2", "S1"}; !reflect.DeepEqual(cited, want) {
+		t.Errorf("cited = %v, want %v", cited, want)
+	}
+}
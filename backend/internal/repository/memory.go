@@ -0,0 +1,645 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"note-llm/internal/chunking"
+	"note-llm/internal/indexing"
+	"note-llm/internal/models"
+	"note-llm/internal/vectorstore"
+
+	"github.com/google/uuid"
+)
+
+// MemoryNotes keeps notes in process memory. Without an outbox, writes
+// index the note inline into the vector store before returning; with a
+// nil store nothing is indexed and notes stay pending.
+type MemoryNotes struct {
+	mu        sync.Mutex
+	notes     map[string]models.Note
+	revisions map[string][]models.NoteRevision
+
+	vectors  vectorstore.VectorStore
+	chunking chunking.Options
+	// indexMu runs one sync at a time, so that vectors written for an
+	// older version of a note can't land after those of a newer one.
+	indexMu sync.Mutex
+}
+
+// NewMemoryNotes indexes into vectors, splitting notes as chunkOpts says.
+func NewMemoryNotes(vectors vectorstore.VectorStore, chunkOpts chunking.Options) *MemoryNotes {
+	return &MemoryNotes{
+		notes:     map[string]models.Note{},
+		revisions: map[string][]models.NoteRevision{},
+		vectors:   vectors,
+		chunking:  chunkOpts,
+	}
+}
+
+// sync makes the vector store match the note's current state, like the
+// outbox does for MongoDB, and records the outcome in the note. Indexing
+// failures are only recorded: the write that triggered it has succeeded.
+func (m *MemoryNotes) sync(ctx context.Context, noteID string) error {
+	if m.vectors == nil {
+		return nil
+	}
+	m.indexMu.Lock()
+	defer m.indexMu.Unlock()
+
+	m.mu.Lock()
+	note, ok := m.notes[noteID]
+	m.mu.Unlock()
+	if !ok || note.DeletedAt != nil {
+		return m.vectors.Delete(ctx, noteID)
+	}
+
+	indexing.IndexNote(ctx, m.vectors, &note, m.chunking)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if current, ok := m.notes[noteID]; ok && current.Version == note.Version {
+		current.IndexStatus = note.IndexStatus
+		current.IndexError = note.IndexError
+		current.IndexedAt = note.IndexedAt
+		m.notes[noteID] = current
+	}
+	return nil
+}
+
+// lookup returns the user's note, which must be in the trash or out of it
+// as trashed says.
+func (m *MemoryNotes) lookup(noteID, userID string, trashed bool) (models.Note, bool) {
+	note, ok := m.notes[noteID]
+	if !ok || note.UserID != userID || (note.DeletedAt != nil) != trashed {
+		return models.Note{}, false
+	}
+	return note, true
+}
+
+func (m *MemoryNotes) Create(ctx context.Context, note models.Note) error {
+	m.mu.Lock()
+
+	if _, ok := m.notes[note.ID]; ok {
+		m.mu.Unlock()
+		return errors.New("duplicate note ID")
+	}
+	m.notes[note.ID] = note
+	m.mu.Unlock()
+
+	return m.sync(ctx, note.ID)
+}
+
+func (m *MemoryNotes) Get(ctx context.Context, noteID, userID string) (*models.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	note, ok := m.lookup(noteID, userID, false)
+	if !ok {
+		return nil, nil
+	}
+	return &note, nil
+}
+
+// memoryCursor is the position after the last note of a page. Unlike the
+// MongoDB cursor it names the note itself, so it goes stale once that note
+// is deleted or leaves the filter.
+type memoryCursor struct {
+	Sort string `json:"s"`
+	Desc bool   `json:"d"`
+	ID   string `json:"id"`
+}
+
+func (m *MemoryNotes) List(ctx context.Context, userID string, q models.NoteListQuery) (*models.NotePage, error) {
+	var key func(models.Note) string
+	switch q.Sort {
+	case models.NoteSortCreated:
+		key = func(n models.Note) string { return n.CreatedAt.UTC().Format(time.RFC3339Nano) }
+	case models.NoteSortModified:
+		key = func(n models.Note) string { return n.ModifiedAt.UTC().Format(time.RFC3339Nano) }
+	case models.NoteSortTitle:
+		key = func(n models.Note) string { return n.Title }
+	default:
+		return nil, errors.New("unknown sort")
+	}
+
+	m.mu.Lock()
+	notes := []models.Note{}
+	for _, note := range m.notes {
+		if note.UserID == userID && note.DeletedAt == nil && q.Filter.Matches(note) {
+			notes = append(notes, note)
+		}
+	}
+	m.mu.Unlock()
+
+	slices.SortFunc(notes, func(a, b models.Note) int {
+		c := cmp.Or(cmp.Compare(key(a), key(b)), cmp.Compare(a.ID, b.ID))
+		if q.Desc {
+			return -c
+		}
+		return c
+	})
+
+	if q.Cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		var c memoryCursor
+		if err := json.Unmarshal(raw, &c); err != nil || c.Sort != q.Sort || c.Desc != q.Desc {
+			return nil, ErrInvalidCursor
+		}
+		i := slices.IndexFunc(notes, func(n models.Note) bool { return n.ID == c.ID })
+		if i < 0 {
+			return nil, ErrInvalidCursor
+		}
+		notes = notes[i+1:]
+	}
+
+	page := &models.NotePage{}
+	if len(notes) > q.Limit {
+		notes = notes[:q.Limit]
+		raw, _ := json.Marshal(memoryCursor{Sort: q.Sort, Desc: q.Desc, ID: notes[q.Limit-1].ID})
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	page.Notes = models.NewNoteResponses(notes)
+	return page, nil
+}
+
+func (m *MemoryNotes) Update(ctx context.Context, noteID, userID string, version int64, change models.UpdateNoteRequest) (*models.Note, error) {
+	if err := m.update(noteID, userID, version, change); err != nil {
+		return nil, err
+	}
+	if err := m.sync(ctx, noteID); err != nil {
+		return nil, err
+	}
+	return m.current(noteID, userID)
+}
+
+func (m *MemoryNotes) update(noteID, userID string, version int64, change models.UpdateNoteRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	note, ok := m.lookup(noteID, userID, false)
+	if !ok {
+		return ErrNotFound
+	}
+	if note.Version != version {
+		return ErrVersionConflict
+	}
+	m.saveRevision(note)
+
+	note.Title = change.Title
+	note.Content = change.Content
+	if change.Tags != nil {
+		note.Tags = models.NormalizeTags(change.Tags)
+	}
+	if change.NotebookID != nil {
+		note.NotebookID = *change.NotebookID
+	}
+	note.ModifiedAt = time.Now()
+	note.IndexStatus = models.IndexStatusPending
+	note.IndexError = ""
+	note.Version++
+	m.notes[noteID] = note
+	return nil
+}
+
+// current returns the note as it is now, after a write and its sync.
+func (m *MemoryNotes) current(noteID, userID string) (*models.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	note, ok := m.lookup(noteID, userID, false)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &note, nil
+}
+
+func (m *MemoryNotes) saveRevision(note models.Note) {
+	revs := m.revisions[note.ID]
+	m.revisions[note.ID] = append(revs, models.NoteRevision{
+		ID:         uuid.New().String(),
+		NoteID:     note.ID,
+		UserID:     note.UserID,
+		Number:     len(revs) + 1,
+		Title:      note.Title,
+		Content:    note.Content,
+		NotebookID: note.NotebookID,
+		Tags:       note.Tags,
+		ModifiedAt: note.ModifiedAt,
+		CreatedAt:  time.Now(),
+	})
+}
+
+func (m *MemoryNotes) Trash(ctx context.Context, noteID, userID string, version int64) error {
+	m.mu.Lock()
+	note, ok := m.lookup(noteID, userID, false)
+	if !ok {
+		m.mu.Unlock()
+		return ErrNotFound
+	}
+	if note.Version != version {
+		m.mu.Unlock()
+		return ErrVersionConflict
+	}
+	now := time.Now()
+	note.DeletedAt = &now
+	m.notes[noteID] = note
+	m.mu.Unlock()
+
+	return m.sync(ctx, noteID)
+}
+
+func (m *MemoryNotes) ListTrash(ctx context.Context, userID string) ([]models.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	notes := []models.Note{}
+	for _, note := range m.notes {
+		if note.UserID == userID && note.DeletedAt != nil {
+			notes = append(notes, note)
+		}
+	}
+	slices.SortFunc(notes, func(a, b models.Note) int {
+		return b.DeletedAt.Compare(*a.DeletedAt)
+	})
+	return notes, nil
+}
+
+func (m *MemoryNotes) GetTrashed(ctx context.Context, noteID, userID string) (*models.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	note, ok := m.lookup(noteID, userID, true)
+	if !ok {
+		return nil, nil
+	}
+	return &note, nil
+}
+
+func (m *MemoryNotes) Restore(ctx context.Context, noteID, userID string, version int64) (*models.Note, error) {
+	m.mu.Lock()
+	note, ok := m.lookup(noteID, userID, true)
+	if !ok {
+		m.mu.Unlock()
+		return nil, ErrNotFound
+	}
+	if note.Version != version {
+		m.mu.Unlock()
+		return nil, ErrVersionConflict
+	}
+	note.DeletedAt = nil
+	note.IndexStatus = models.IndexStatusPending
+	note.IndexError = ""
+	m.notes[noteID] = note
+	m.mu.Unlock()
+
+	if err := m.sync(ctx, noteID); err != nil {
+		return nil, err
+	}
+	return m.current(noteID, userID)
+}
+
+func (m *MemoryNotes) Purge(ctx context.Context, noteID, userID string, version int64) error {
+	m.mu.Lock()
+	note, ok := m.lookup(noteID, userID, true)
+	if !ok {
+		m.mu.Unlock()
+		return ErrNotFound
+	}
+	if note.Version != version {
+		m.mu.Unlock()
+		return ErrVersionConflict
+	}
+	delete(m.notes, noteID)
+	delete(m.revisions, noteID)
+	m.mu.Unlock()
+
+	return m.sync(ctx, noteID)
+}
+
+func (m *MemoryNotes) ListRevisions(ctx context.Context, noteID, userID string) ([]models.NoteRevision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	revs := []models.NoteRevision{}
+	for i := len(m.revisions[noteID]) - 1; i >= 0; i-- {
+		rev := m.revisions[noteID][i]
+		if rev.UserID == userID {
+			rev.Content = ""
+			revs = append(revs, rev)
+		}
+	}
+	return revs, nil
+}
+
+func (m *MemoryNotes) GetRevision(ctx context.Context, noteID, userID string, number int) (*models.NoteRevision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	revs := m.revisions[noteID]
+	if number < 1 || number > len(revs) || revs[number-1].UserID != userID {
+		return nil, nil
+	}
+	rev := revs[number-1]
+	return &rev, nil
+}
+
+// TextSearch scores notes by how often the query's words occur in them,
+// counting title matches three times like the MongoDB text index.
+func (m *MemoryNotes) TextSearch(ctx context.Context, userID, query string, limit int) ([]TextHit, error) {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	m.mu.Lock()
+	var hits []TextHit
+	for _, note := range m.notes {
+		if note.UserID != userID || note.DeletedAt != nil {
+			continue
+		}
+		title, content := strings.ToLower(note.Title), strings.ToLower(note.Content)
+		var score float64
+		for _, w := range words {
+			score += 3*float64(strings.Count(title, w)) + float64(strings.Count(content, w))
+		}
+		if score > 0 {
+			hits = append(hits, TextHit{Note: note, Score: score})
+		}
+	}
+	m.mu.Unlock()
+
+	slices.SortFunc(hits, func(a, b TextHit) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), b.Note.ModifiedAt.Compare(a.Note.ModifiedAt), cmp.Compare(a.Note.ID, b.Note.ID))
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func (m *MemoryNotes) FindMatching(ctx context.Context, noteIDs []string, userID string, f models.NoteFilter) ([]models.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	notes := []models.Note{}
+	for _, id := range noteIDs {
+		if note, ok := m.lookup(id, userID, false); ok && f.Matches(note) {
+			notes = append(notes, note)
+		}
+	}
+	return notes, nil
+}
+
+func (m *MemoryNotes) ExpiredTrash(ctx context.Context, cutoff time.Time, limit int) ([]models.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var notes []models.Note
+	for _, note := range m.notes {
+		if note.DeletedAt != nil && note.DeletedAt.Before(cutoff) {
+			notes = append(notes, note)
+		}
+	}
+	slices.SortFunc(notes, func(a, b models.Note) int { return a.DeletedAt.Compare(*b.DeletedAt) })
+	if len(notes) > limit {
+		notes = notes[:limit]
+	}
+	return notes, nil
+}
+
+func (m *MemoryNotes) ExistingIDs(ctx context.Context, noteIDs []string) (map[string]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing := make(map[string]bool, len(noteIDs))
+	for _, id := range noteIDs {
+		if note, ok := m.notes[id]; ok && note.DeletedAt == nil {
+			existing[id] = true
+		}
+	}
+	return existing, nil
+}
+
+// clearNotebook moves a user's notes out of a deleted notebook.
+func (m *MemoryNotes) clearNotebook(userID, notebookID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, note := range m.notes {
+		if note.UserID == userID && note.NotebookID == notebookID {
+			note.NotebookID = ""
+			note.Version++
+			m.notes[id] = note
+		}
+	}
+}
+
+// MemoryUsers keeps users in process memory.
+type MemoryUsers struct {
+	mu    sync.Mutex
+	users map[string]models.User
+}
+
+func NewMemoryUsers() *MemoryUsers {
+	return &MemoryUsers{users: map[string]models.User{}}
+}
+
+func (m *MemoryUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[email]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (m *MemoryUsers) Create(ctx context.Context, user models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[user.Email]; ok {
+		return errors.New("duplicate user email")
+	}
+	m.users[user.Email] = user
+	return nil
+}
+
+// MemoryNotebooks keeps notebooks in process memory. Deleting one moves its
+// notes in the given MemoryNotes out of it.
+type MemoryNotebooks struct {
+	mu        sync.Mutex
+	notebooks map[string]models.Notebook
+	notes     *MemoryNotes
+}
+
+func NewMemoryNotebooks(notes *MemoryNotes) *MemoryNotebooks {
+	return &MemoryNotebooks{notebooks: map[string]models.Notebook{}, notes: notes}
+}
+
+// nameTaken reports whether another of the user's notebooks has the name.
+// Callers hold mu.
+func (m *MemoryNotebooks) nameTaken(id, userID, name string) bool {
+	for _, nb := range m.notebooks {
+		if nb.UserID == userID && nb.Name == name && nb.ID != id {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryNotebooks) Create(ctx context.Context, notebook models.Notebook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.nameTaken(notebook.ID, notebook.UserID, notebook.Name) {
+		return ErrDuplicateNotebook
+	}
+	m.notebooks[notebook.ID] = notebook
+	return nil
+}
+
+func (m *MemoryNotebooks) List(ctx context.Context, userID string) ([]models.Notebook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	notebooks := []models.Notebook{}
+	for _, nb := range m.notebooks {
+		if nb.UserID == userID {
+			notebooks = append(notebooks, nb)
+		}
+	}
+	slices.SortFunc(notebooks, func(a, b models.Notebook) int { return cmp.Compare(a.Name, b.Name) })
+	return notebooks, nil
+}
+
+func (m *MemoryNotebooks) Get(ctx context.Context, id, userID string) (*models.Notebook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nb, ok := m.notebooks[id]
+	if !ok || nb.UserID != userID {
+		return nil, nil
+	}
+	return &nb, nil
+}
+
+func (m *MemoryNotebooks) Rename(ctx context.Context, id, userID, name string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nb, ok := m.notebooks[id]
+	if !ok || nb.UserID != userID {
+		return false, nil
+	}
+	if m.nameTaken(id, userID, name) {
+		return false, ErrDuplicateNotebook
+	}
+	nb.Name = name
+	nb.UpdatedAt = time.Now()
+	m.notebooks[id] = nb
+	return true, nil
+}
+
+func (m *MemoryNotebooks) Delete(ctx context.Context, id, userID string) (bool, error) {
+	m.mu.Lock()
+	nb, ok := m.notebooks[id]
+	if !ok || nb.UserID != userID {
+		m.mu.Unlock()
+		return false, nil
+	}
+	delete(m.notebooks, id)
+	m.mu.Unlock()
+
+	m.notes.clearNotebook(userID, id)
+	return true, nil
+}
+
+// MemoryConversations keeps conversations in process memory.
+type MemoryConversations struct {
+	mu            sync.Mutex
+	conversations map[string]models.Conversation
+}
+
+func NewMemoryConversations() *MemoryConversations {
+	return &MemoryConversations{conversations: map[string]models.Conversation{}}
+}
+
+func (m *MemoryConversations) Create(ctx context.Context, conv models.Conversation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.conversations[conv.ID]; ok {
+		return errors.New("duplicate conversation ID")
+	}
+	m.conversations[conv.ID] = conv
+	return nil
+}
+
+func (m *MemoryConversations) List(ctx context.Context, userID string) ([]models.Conversation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conversations := []models.Conversation{}
+	for _, conv := range m.conversations {
+		if conv.UserID == userID {
+			conv.Messages = nil
+			conversations = append(conversations, conv)
+		}
+	}
+	slices.SortFunc(conversations, func(a, b models.Conversation) int { return b.UpdatedAt.Compare(a.UpdatedAt) })
+	return conversations, nil
+}
+
+func (m *MemoryConversations) Get(ctx context.Context, id, userID string) (*models.Conversation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conv, ok := m.conversations[id]
+	if !ok || conv.UserID != userID {
+		return nil, nil
+	}
+	conv.Messages = slices.Clone(conv.Messages)
+	return &conv, nil
+}
+
+func (m *MemoryConversations) AppendMessages(ctx context.Context, id, userID, title string, messages ...models.ConversationMessage) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conv, ok := m.conversations[id]
+	if !ok || conv.UserID != userID {
+		return false, nil
+	}
+	conv.Messages = append(slices.Clone(conv.Messages), messages...)
+	conv.UpdatedAt = time.Now()
+	if conv.Title == "" {
+		conv.Title = title
+	}
+	m.conversations[id] = conv
+	return true, nil
+}
+
+func (m *MemoryConversations) Delete(ctx context.Context, id, userID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conv, ok := m.conversations[id]
+	if !ok || conv.UserID != userID {
+		return false, nil
+	}
+	delete(m.conversations, id)
+	return true, nil
+}
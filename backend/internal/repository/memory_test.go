@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"note-llm/internal/chunking"
+	"note-llm/internal/models"
+)
+
+func TestMemoryListPages(t *testing.T) {
+	ctx := context.Background()
+	notes := NewMemoryNotes(nil, chunking.Options{})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		note := models.Note{
+			ID:        fmt.Sprintf("n%d", i),
+			UserID:    "u1",
+			Title:     fmt.Sprintf("note %d", i),
+			CreatedAt: start.Add(time.Duration(i) * time.Hour),
+		}
+		if err := notes.Create(ctx, note); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q := models.NoteListQuery{Sort: models.NoteSortCreated, Desc: true, Limit: 2}
+	var got []string
+	for {
+		page, err := notes.List(ctx, "u1", q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range page.Notes {
+			got = append(got, n.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	if fmt.Sprint(got) != "[n4 n3 n2 n1 n0]" {
+		t.Fatalf("paged through %v", got)
+	}
+
+	q.Cursor = "bogus"
+	if _, err := notes.List(ctx, "u1", q); err != ErrInvalidCursor {
+		t.Errorf("bogus cursor: err = %v, want ErrInvalidCursor", err)
+	}
+	first, _ := notes.List(ctx, "u1", models.NoteListQuery{Sort: models.NoteSortCreated, Desc: true, Limit: 2})
+	q.Cursor, q.Sort = first.NextCursor, models.NoteSortTitle
+	if _, err := notes.List(ctx, "u1", q); err != ErrInvalidCursor {
+		t.Errorf("cursor for another sort: err = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"note-llm/internal/db"
+	"note-llm/internal/models"
+	"note-llm/internal/outbox"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MongoNotes stores notes in MongoDB. Each write runs in one transaction
+// with its outbox entry, which is handed to the indexer once committed.
+type MongoNotes struct{}
+
+func NewMongoNotes() *MongoNotes {
+	return &MongoNotes{}
+}
+
+func (MongoNotes) Create(ctx context.Context, note models.Note) error {
+	var entry *models.OutboxEntry
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := db.InsertNote(ctx, note); err != nil {
+			return err
+		}
+		var err error
+		entry, err = db.EnqueueIndexOp(ctx, note.ID, note.UserID, models.IndexOpUpsert)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	outbox.Notify(entry)
+	return nil
+}
+
+func (MongoNotes) Get(ctx context.Context, noteID, userID string) (*models.Note, error) {
+	return db.FetchNoteByID(ctx, noteID, userID)
+}
+
+func (MongoNotes) List(ctx context.Context, userID string, q models.NoteListQuery) (*models.NotePage, error) {
+	return db.ListNotes(ctx, userID, q)
+}
+
+func (MongoNotes) Update(ctx context.Context, noteID, userID string, version int64, change models.UpdateNoteRequest) (*models.Note, error) {
+	set := bson.M{
+		"title":        change.Title,
+		"content":      change.Content,
+		"modified_at":  time.Now(),
+		"index_status": models.IndexStatusPending,
+		"index_error":  "",
+	}
+	update := bson.M{"$set": set}
+	if change.Tags != nil {
+		set["tags"] = models.NormalizeTags(change.Tags)
+	}
+	if change.NotebookID != nil {
+		if *change.NotebookID == "" {
+			update["$unset"] = bson.M{"notebook_id": ""}
+		} else {
+			set["notebook_id"] = *change.NotebookID
+		}
+	}
+
+	var entry *models.OutboxEntry
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		current, err := db.FetchNoteByID(ctx, noteID, userID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrNotFound
+		}
+		if current.Version != version {
+			return ErrVersionConflict
+		}
+		if _, err := db.SaveRevision(ctx, *current); err != nil {
+			return err
+		}
+		updated, err := db.UpdateNoteVersion(ctx, noteID, userID, version, update)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return ErrVersionConflict
+		}
+		entry, err = db.EnqueueIndexOp(ctx, noteID, userID, models.IndexOpUpsert)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	outbox.Notify(entry)
+
+	note, err := db.FetchNoteByID(ctx, noteID, userID)
+	if err == nil && note == nil {
+		err = ErrNotFound
+	}
+	return note, err
+}
+
+// Trash hides the note from search right away; the outbox removes its
+// vectors and Restore re-indexes it.
+func (MongoNotes) Trash(ctx context.Context, noteID, userID string, version int64) error {
+	var entry *models.OutboxEntry
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		current, err := db.FetchNoteByID(ctx, noteID, userID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrNotFound
+		}
+		trashed, err := db.TrashNote(ctx, noteID, userID, version)
+		if err != nil {
+			return err
+		}
+		if !trashed {
+			return ErrVersionConflict
+		}
+		entry, err = db.EnqueueIndexOp(ctx, noteID, userID, models.IndexOpDelete)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	outbox.Notify(entry)
+	return nil
+}
+
+func (MongoNotes) ListTrash(ctx context.Context, userID string) ([]models.Note, error) {
+	return db.ListTrash(ctx, userID)
+}
+
+func (MongoNotes) GetTrashed(ctx context.Context, noteID, userID string) (*models.Note, error) {
+	return db.FetchTrashedNote(ctx, noteID, userID)
+}
+
+func (MongoNotes) Restore(ctx context.Context, noteID, userID string, version int64) (*models.Note, error) {
+	var entry *models.OutboxEntry
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		current, err := db.FetchTrashedNote(ctx, noteID, userID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrNotFound
+		}
+		restored, err := db.UntrashNote(ctx, noteID, userID, version)
+		if err != nil {
+			return err
+		}
+		if !restored {
+			return ErrVersionConflict
+		}
+		entry, err = db.EnqueueIndexOp(ctx, noteID, userID, models.IndexOpUpsert)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	outbox.Notify(entry)
+
+	note, err := db.FetchNoteByID(ctx, noteID, userID)
+	if err == nil && note == nil {
+		err = ErrNotFound
+	}
+	return note, err
+}
+
+func (MongoNotes) Purge(ctx context.Context, noteID, userID string, version int64) error {
+	var entry *models.OutboxEntry
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		current, err := db.FetchTrashedNote(ctx, noteID, userID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrNotFound
+		}
+		purged, err := db.PurgeTrashedNote(ctx, noteID, userID, version)
+		if err != nil {
+			return err
+		}
+		if !purged {
+			return ErrVersionConflict
+		}
+		entry, err = db.EnqueueIndexOp(ctx, noteID, userID, models.IndexOpDelete)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	outbox.Notify(entry)
+	return nil
+}
+
+func (MongoNotes) ListRevisions(ctx context.Context, noteID, userID string) ([]models.NoteRevision, error) {
+	return db.ListRevisions(ctx, noteID, userID)
+}
+
+func (MongoNotes) GetRevision(ctx context.Context, noteID, userID string, number int) (*models.NoteRevision, error) {
+	return db.FetchRevision(ctx, noteID, userID, number)
+}
+
+func (MongoNotes) TextSearch(ctx context.Context, userID, query string, limit int) ([]TextHit, error) {
+	return db.TextSearchNotes(ctx, userID, query, int64(limit))
+}
+
+func (MongoNotes) FindMatching(ctx context.Context, noteIDs []string, userID string, f models.NoteFilter) ([]models.Note, error) {
+	return db.FetchMatchingNotes(ctx, noteIDs, userID, f)
+}
+
+func (MongoNotes) ExpiredTrash(ctx context.Context, cutoff time.Time, limit int) ([]models.Note, error) {
+	return db.ExpiredTrash(ctx, cutoff, int64(limit))
+}
+
+func (MongoNotes) ExistingIDs(ctx context.Context, noteIDs []string) (map[string]bool, error) {
+	return db.ExistingNoteIDs(ctx, noteIDs)
+}
+
+// MongoUsers stores users in MongoDB.
+type MongoUsers struct{}
+
+func NewMongoUsers() *MongoUsers {
+	return &MongoUsers{}
+}
+
+func (MongoUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return db.FetchUserByEmail(ctx, email)
+}
+
+func (MongoUsers) Create(ctx context.Context, user models.User) error {
+	return db.InsertUser(ctx, user)
+}
+
+// MongoNotebooks stores notebooks in MongoDB.
+type MongoNotebooks struct{}
+
+func NewMongoNotebooks() *MongoNotebooks {
+	return &MongoNotebooks{}
+}
+
+func (MongoNotebooks) Create(ctx context.Context, notebook models.Notebook) error {
+	return db.InsertNotebook(ctx, notebook)
+}
+
+func (MongoNotebooks) List(ctx context.Context, userID string) ([]models.Notebook, error) {
+	return db.ListNotebooks(ctx, userID)
+}
+
+func (MongoNotebooks) Get(ctx context.Context, id, userID string) (*models.Notebook, error) {
+	return db.FetchNotebook(ctx, id, userID)
+}
+
+func (MongoNotebooks) Rename(ctx context.Context, id, userID, name string) (bool, error) {
+	return db.RenameNotebook(ctx, id, userID, name)
+}
+
+func (MongoNotebooks) Delete(ctx context.Context, id, userID string) (bool, error) {
+	return db.DeleteNotebook(ctx, id, userID)
+}
+
+// MongoConversations stores conversations in MongoDB.
+type MongoConversations struct{}
+
+func NewMongoConversations() *MongoConversations {
+	return &MongoConversations{}
+}
+
+func (MongoConversations) Create(ctx context.Context, conv models.Conversation) error {
+	return db.InsertConversation(ctx, conv)
+}
+
+func (MongoConversations) List(ctx context.Context, userID string) ([]models.Conversation, error) {
+	return db.ListConversations(ctx, userID)
+}
+
+func (MongoConversations) Get(ctx context.Context, id, userID string) (*models.Conversation, error) {
+	return db.FetchConversation(ctx, id, userID)
+}
+
+func (MongoConversations) AppendMessages(ctx context.Context, id, userID, title string, messages ...models.ConversationMessage) (bool, error) {
+	return db.AppendConversationMessages(ctx, id, userID, title, messages...)
+}
+
+func (MongoConversations) Delete(ctx context.Context, id, userID string) (bool, error) {
+	return db.DeleteConversation(ctx, id, userID)
+}
@@ -0,0 +1,122 @@
+// Package repository is the storage behind the API. The MongoDB
+// implementations are used in production; the in-memory ones let the HTTP
+// server run in tests and demos without external services.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"note-llm/internal/db"
+	"note-llm/internal/models"
+)
+
+var (
+	// ErrNotFound is returned for notes that don't exist, belong to
+	// someone else or, outside of the trash methods, are in the trash.
+	ErrNotFound = errors.New("note not found")
+	// ErrVersionConflict is returned when a note changed since the version
+	// the caller passed.
+	ErrVersionConflict = errors.New("note was modified")
+	// ErrInvalidCursor is returned by List for a cursor that is malformed
+	// or was issued for a different sort order.
+	ErrInvalidCursor = db.ErrInvalidCursor
+	// ErrDuplicateNotebook is returned when a user already has a notebook
+	// with the same name.
+	ErrDuplicateNotebook = db.ErrDuplicateNotebook
+)
+
+// TextHit is a full-text match with its relevance score.
+type TextHit = db.TextHit
+
+// NoteRepository stores notes with their revisions. Writes that change
+// what a note's search index should hold also queue re-indexing.
+type NoteRepository interface {
+	// Create stores a new note.
+	Create(ctx context.Context, note models.Note) error
+	// Get returns nil without an error when the note does not exist or is
+	// in the trash.
+	Get(ctx context.Context, noteID, userID string) (*models.Note, error)
+	// List returns one page of a user's notes.
+	List(ctx context.Context, userID string, q models.NoteListQuery) (*models.NotePage, error)
+	// Update applies change to a note that is still at version, saving its
+	// previous state as a revision and bumping the version, and returns
+	// the updated note.
+	Update(ctx context.Context, noteID, userID string, version int64, change models.UpdateNoteRequest) (*models.Note, error)
+	// Trash moves a note that is still at version to the trash.
+	Trash(ctx context.Context, noteID, userID string, version int64) error
+
+	// ListTrash returns a user's trashed notes, most recently deleted
+	// first.
+	ListTrash(ctx context.Context, userID string) ([]models.Note, error)
+	// GetTrashed returns nil without an error when the note is not in the
+	// user's trash.
+	GetTrashed(ctx context.Context, noteID, userID string) (*models.Note, error)
+	// Restore takes a trashed note that is still at version out of the
+	// trash and returns it.
+	Restore(ctx context.Context, noteID, userID string, version int64) (*models.Note, error)
+	// Purge permanently deletes a trashed note that is still at version
+	// with its revisions.
+	Purge(ctx context.Context, noteID, userID string, version int64) error
+
+	// ListRevisions returns a note's revisions without their content,
+	// newest first.
+	ListRevisions(ctx context.Context, noteID, userID string) ([]models.NoteRevision, error)
+	// GetRevision returns nil without an error when the revision does not
+	// exist.
+	GetRevision(ctx context.Context, noteID, userID string, number int) (*models.NoteRevision, error)
+
+	// TextSearch runs a full-text query over a user's notes outside the
+	// trash, best match first.
+	TextSearch(ctx context.Context, userID, query string, limit int) ([]TextHit, error)
+	// FindMatching returns the given notes that are outside the trash and
+	// match f, in the order of noteIDs.
+	FindMatching(ctx context.Context, noteIDs []string, userID string, f models.NoteFilter) ([]models.Note, error)
+
+	// ExpiredTrash returns up to limit notes, of any user, that were
+	// trashed before cutoff.
+	ExpiredTrash(ctx context.Context, cutoff time.Time, limit int) ([]models.Note, error)
+	// ExistingIDs reports which of the given notes, of any user, exist
+	// outside the trash.
+	ExistingIDs(ctx context.Context, noteIDs []string) (map[string]bool, error)
+}
+
+// NotebookRepository stores the notebooks that group a user's notes.
+type NotebookRepository interface {
+	// Create returns ErrDuplicateNotebook when the name is taken.
+	Create(ctx context.Context, notebook models.Notebook) error
+	// List returns a user's notebooks sorted by name.
+	List(ctx context.Context, userID string) ([]models.Notebook, error)
+	// Get returns nil without an error when the notebook does not exist.
+	Get(ctx context.Context, id, userID string) (*models.Notebook, error)
+	// Rename reports whether the notebook was found. It returns
+	// ErrDuplicateNotebook when the name is taken.
+	Rename(ctx context.Context, id, userID, name string) (bool, error)
+	// Delete deletes a notebook and moves its notes out of it, bumping
+	// their versions. It reports whether the notebook was found.
+	Delete(ctx context.Context, id, userID string) (bool, error)
+}
+
+// ConversationRepository stores conversations with their messages.
+type ConversationRepository interface {
+	Create(ctx context.Context, conv models.Conversation) error
+	// List returns a user's conversations without their messages, most
+	// recently active first.
+	List(ctx context.Context, userID string) ([]models.Conversation, error)
+	// Get returns nil without an error when the conversation does not
+	// exist.
+	Get(ctx context.Context, id, userID string) (*models.Conversation, error)
+	// AppendMessages adds turns to a conversation, setting its title if it
+	// doesn't have one yet. It reports whether the conversation was found.
+	AppendMessages(ctx context.Context, id, userID, title string, messages ...models.ConversationMessage) (bool, error)
+	// Delete reports whether a conversation was deleted.
+	Delete(ctx context.Context, id, userID string) (bool, error)
+}
+
+// UserRepository stores the accounts created on first sign-in.
+type UserRepository interface {
+	// FindByEmail returns nil without an error when no user has the email.
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	Create(ctx context.Context, user models.User) error
+}
@@ -0,0 +1,138 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// Reranker scores documents by relevance to query, returning one score
+// per document in input order. Scores are only meaningful relative to each
+// other. A nil result keeps the retrieval order.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, documents []string) ([]float64, error)
+}
+
+// Factory builds a Reranker from the current viper config.
+type Factory func() (Reranker, error)
+
+var (
+	rerankersMu sync.RWMutex
+	rerankers   = map[string]Factory{}
+
+	defaultReranker     Reranker
+	defaultRerankerOnce sync.Once
+	defaultRerankerErr  error
+	defaultSettings     settings
+)
+
+// settings are the RERANK_* options that apply to every request.
+type settings struct {
+	provider        string
+	enabled         bool
+	candidateFactor int
+}
+
+// settingsFromViper resolves the defaults in code rather than with
+// viper.SetDefault, which isn't safe while requests read config.
+func settingsFromViper() settings {
+	s := settings{provider: strings.ToLower(viper.GetString("RERANK_PROVIDER")), candidateFactor: 3}
+	if s.provider == "" {
+		s.provider = "none"
+	}
+	s.enabled = s.provider != "none"
+	if viper.IsSet("RERANK_ENABLED") {
+		s.enabled = viper.GetBool("RERANK_ENABLED")
+	}
+	if viper.IsSet("RERANK_CANDIDATE_FACTOR") {
+		s.candidateFactor = max(viper.GetInt("RERANK_CANDIDATE_FACTOR"), 1)
+	}
+	return s
+}
+
+// loadDefault reads the settings and builds the configured reranker, once.
+func loadDefault() {
+	defaultRerankerOnce.Do(func() {
+		defaultSettings = settingsFromViper()
+		defaultReranker, defaultRerankerErr = New(defaultSettings.provider)
+	})
+}
+
+func init() {
+	Register("none", func() (Reranker, error) { return noop{}, nil })
+}
+
+// Register makes a reranker selectable through the RERANK_PROVIDER config
+// key. It panics on duplicate names.
+func Register(name string, factory Factory) {
+	rerankersMu.Lock()
+	defer rerankersMu.Unlock()
+
+	name = strings.ToLower(name)
+	if _, exists := rerankers[name]; exists {
+		panic(fmt.Sprintf("rerank: reranker %q already registered", name))
+	}
+	rerankers[name] = factory
+}
+
+// New builds the registered reranker with the given name.
+func New(name string) (Reranker, error) {
+	rerankersMu.RLock()
+	factory, ok := rerankers[strings.ToLower(name)]
+	rerankersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown rerank provider %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return factory()
+}
+
+// Names lists the registered rerankers.
+func Names() []string {
+	rerankersMu.RLock()
+	defer rerankersMu.RUnlock()
+
+	names := make([]string, 0, len(rerankers))
+	for name := range rerankers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProviderName returns the configured RERANK_PROVIDER (defaults to "none").
+func ProviderName() string {
+	loadDefault()
+	return defaultSettings.provider
+}
+
+// Default returns the reranker selected by RERANK_PROVIDER. It is built
+// once and shared.
+func Default() (Reranker, error) {
+	loadDefault()
+	return defaultReranker, defaultRerankerErr
+}
+
+// Enabled reports whether reranking runs by default (RERANK_ENABLED,
+// defaults to true whenever a provider other than "none" is configured).
+func Enabled() bool {
+	loadDefault()
+	return defaultSettings.enabled
+}
+
+// CandidateFactor is how many candidates are retrieved per result kept
+// after reranking (RERANK_CANDIDATE_FACTOR, defaults to 3).
+func CandidateFactor() int {
+	loadDefault()
+	return defaultSettings.candidateFactor
+}
+
+// noop keeps the retrieval order.
+type noop struct{}
+
+func (noop) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	return nil, nil
+}
@@ -0,0 +1,92 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	Register("http", newHTTPReranker)
+	viper.SetDefault("RERANK_TIMEOUT", 10*time.Second)
+}
+
+// httpReranker calls a cross-encoder served over HTTP with the
+// text-embeddings-inference rerank API: POST {base}/rerank with
+// {"query", "texts"}, answered by [{"index", "score"}].
+type httpReranker struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// newHTTPReranker requires RERANK_BASE_URL: there is no sensible default,
+// and guessing a local port risks posting to some other service.
+func newHTTPReranker() (Reranker, error) {
+	baseURL := strings.TrimRight(viper.GetString("RERANK_BASE_URL"), "/")
+	if baseURL == "" {
+		return nil, fmt.Errorf("Missing RERANK_BASE_URL in config")
+	}
+
+	return &httpReranker{
+		httpClient: &http.Client{Timeout: viper.GetDuration("RERANK_TIMEOUT")},
+		baseURL:    baseURL,
+	}, nil
+}
+
+type httpRerankRequest struct {
+	Query string   `json:"query"`
+	Texts []string `json:"texts"`
+}
+
+type httpRerankResult struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+func (r *httpReranker) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	body, err := json.Marshal(httpRerankRequest{Query: query, Texts: documents})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+"/rerank", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rerank failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("rerank failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rerank failed: %s: %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+
+	var results []httpRerankResult
+	if err := json.Unmarshal(raw, &results); err != nil {
+		return nil, fmt.Errorf("rerank failed: %w", err)
+	}
+
+	scores := make([]float64, len(documents))
+	for _, res := range results {
+		if res.Index < 0 || res.Index >= len(documents) {
+			return nil, fmt.Errorf("rerank failed: index %d out of range", res.Index)
+		}
+		scores[res.Index] = res.Score
+	}
+	return scores, nil
+}
@@ -0,0 +1,50 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestHTTPReranker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req httpRerankRequest
+		if r.URL.Path != "/rerank" || json.NewDecoder(r.Body).Decode(&req) != nil || req.Query != "rye" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		// Results come back sorted by score, not in input order.
+		json.NewEncoder(w).Encode([]httpRerankResult{{Index: 2, Score: 0.9}, {Index: 0, Score: 0.5}, {Index: 1, Score: 0.1}})
+	}))
+	defer srv.Close()
+
+	viper.Set("RERANK_BASE_URL", srv.URL+"/")
+	t.Cleanup(func() { viper.Set("RERANK_BASE_URL", nil) })
+	reranker, err := newHTTPReranker()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scores, err := reranker.Rerank(context.Background(), "rye", []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{0.5, 0.1, 0.9}; !reflect.DeepEqual(scores, want) {
+		t.Errorf("scores = %v, want %v", scores, want)
+	}
+
+	if _, err := reranker.Rerank(context.Background(), "wheat", []string{"a"}); err == nil {
+		t.Error("error status not reported")
+	}
+}
+
+func TestHTTPRerankerNeedsBaseURL(t *testing.T) {
+	if _, err := newHTTPReranker(); err == nil {
+		t.Fatal("built without RERANK_BASE_URL")
+	}
+}
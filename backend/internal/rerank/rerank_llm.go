@@ -0,0 +1,71 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"note-llm/internal/llm"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	Register("llm", newLLMReranker)
+}
+
+// llmReranker asks the chat model to grade every passage in one call.
+// RERANK_MODEL picks the model; empty uses the chat default.
+type llmReranker struct {
+	model string
+}
+
+func newLLMReranker() (Reranker, error) {
+	return &llmReranker{model: viper.GetString("RERANK_MODEL")}, nil
+}
+
+// gradeLine matches "<passage number>: <score>" lines in the judge's reply.
+var gradeLine = regexp.MustCompile(`(?m)^\s*\[?(\d+)\]?\s*[:=-]\s*(\d+(?:\.\d+)?)`)
+
+func (r *llmReranker) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	chat, err := llm.DefaultChatModel()
+	if err != nil {
+		return nil, err
+	}
+
+	var passages strings.Builder
+	for i, doc := range documents {
+		fmt.Fprintf(&passages, "[%d]\n%s\n\n", i+1, doc)
+	}
+	prompt := fmt.Sprintf(`Rate how relevant each passage is to the query on a scale from 0 (unrelated) to 10 (answers it directly).
+
+Reply with one line per passage in the form "<passage number>: <score>" and nothing else.
+
+Query:
+%s
+
+Passages:
+%s`, query, passages.String())
+
+	reply, err := chat.Complete(ctx, llm.ChatRequest{
+		Model:    r.model,
+		Messages: []llm.ChatMessage{{Role: llm.RoleUser, Content: prompt}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rerank failed: %w", err)
+	}
+
+	// Passages the judge skipped score 0 and sink to the bottom.
+	scores := make([]float64, len(documents))
+	for _, m := range gradeLine.FindAllStringSubmatch(reply, -1) {
+		n, _ := strconv.Atoi(m[1])
+		score, err := strconv.ParseFloat(m[2], 64)
+		if err != nil || n < 1 || n > len(documents) {
+			continue
+		}
+		scores[n-1] = score
+	}
+	return scores, nil
+}
@@ -0,0 +1,33 @@
+package rerank
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSettingsFromViper(t *testing.T) {
+	keys := []string{"RERANK_PROVIDER", "RERANK_ENABLED", "RERANK_CANDIDATE_FACTOR"}
+	set := func(values ...any) {
+		t.Helper()
+		for i, key := range keys {
+			viper.Set(key, values[i])
+		}
+	}
+	t.Cleanup(func() { set(nil, nil, nil) })
+
+	for name, tc := range map[string]struct {
+		values []any
+		want   settings
+	}{
+		"unset":         {[]any{nil, nil, nil}, settings{provider: "none", enabled: false, candidateFactor: 3}},
+		"provider only": {[]any{"HTTP", nil, nil}, settings{provider: "http", enabled: true, candidateFactor: 3}},
+		"turned off":    {[]any{"http", false, 5}, settings{provider: "http", enabled: false, candidateFactor: 5}},
+		"bad factor":    {[]any{"llm", nil, 0}, settings{provider: "llm", enabled: true, candidateFactor: 1}},
+	} {
+		set(tc.values...)
+		if got := settingsFromViper(); got != tc.want {
+			t.Errorf("%s: settings = %+v, want %+v", name, got, tc.want)
+		}
+	}
+}
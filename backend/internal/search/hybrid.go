@@ -0,0 +1,229 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"note-llm/internal/models"
+	"note-llm/internal/tokenizer"
+
+	"github.com/spf13/viper"
+)
+
+// snippetRadius is how many bytes of context a snippet keeps on each side
+// of the first highlighted term.
+const snippetRadius = 120
+
+func init() {
+	viper.SetDefault("SEARCH_RRF_K", 60)
+}
+
+// Result is one note returned by HybridSearch. Ranks are 1-based positions
+// in each retriever's list, zero when the retriever didn't return the note.
+type Result struct {
+	Note        models.NoteResponse `json:"note"`
+	Score       float64             `json:"score"`
+	TextRank    int                 `json:"text_rank,omitempty"`
+	TextScore   float64             `json:"text_score,omitempty"`
+	VectorRank  int                 `json:"vector_rank,omitempty"`
+	VectorScore float32             `json:"vector_score,omitempty"`
+	Snippet     string              `json:"snippet"`
+}
+
+// HybridSearch combines full-text matches from stores.Notes with vector hits
+// using reciprocal rank fusion: each note scores sum(1 / (k + rank)) over
+// the lists it appears in, so notes found by both retrievers rise to the
+// top without having to calibrate BM25 scores against cosine similarity.
+//
+// opts.Limit is the number of notes returned; MinScore and MMR apply to
+// the vector candidates.
+func HybridSearch(ctx context.Context, stores Stores, userID, query string, opts Options) ([]Result, error) {
+	k := float64(viper.GetInt("SEARCH_RRF_K"))
+	opts = opts.WithDefaults()
+	limit := opts.Limit
+	candidates := min(max(limit*2, 20), MaxLimit)
+
+	textHits, err := stores.Notes.TextSearch(ctx, userID, query, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("text search failed: %w", err)
+	}
+
+	vectorOpts := opts
+	vectorOpts.Limit = candidates
+	chunkHits, err := SearchRelevantChunks(ctx, stores.Vectors, userID, query, vectorOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[string]*Result{}
+	for i, hit := range textHits {
+		note := hit.Note
+		results[note.ID] = &Result{Note: models.NewNoteResponse(note), TextRank: i + 1, TextScore: hit.Score}
+	}
+
+	// Chunks of the same note share one vector rank: that of its best chunk.
+	bestChunk := map[string]ChunkHit{}
+	vectorRank := 0
+	for _, hit := range chunkHits {
+		if _, seen := bestChunk[hit.NoteID]; seen {
+			continue
+		}
+		bestChunk[hit.NoteID] = hit
+		vectorRank++
+
+		r, ok := results[hit.NoteID]
+		if !ok {
+			r = &Result{Note: models.NoteResponse{ID: hit.NoteID}}
+			results[hit.NoteID] = r
+		}
+		r.VectorRank = vectorRank
+		r.VectorScore = hit.Score
+	}
+
+	// Vector-only hits still need their note body.
+	var missing []string
+	for id, r := range results {
+		if r.TextRank == 0 {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		notes, err := stores.Notes.FindMatching(ctx, missing, userID, models.NoteFilter{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch notes from DB: %w", err)
+		}
+		for _, note := range notes {
+			results[note.ID].Note = models.NewNoteResponse(note)
+		}
+	}
+
+	terms := queryTerms(query)
+	ranked := make([]Result, 0, len(results))
+	for id, r := range results {
+		// Deleted since it was indexed.
+		if r.Note.UserID == "" {
+			continue
+		}
+		if r.TextRank > 0 {
+			r.Score += 1 / (k + float64(r.TextRank))
+		}
+		if r.VectorRank > 0 {
+			r.Score += 1 / (k + float64(r.VectorRank))
+		}
+		r.Snippet = highlight(r.Note.Content, terms, bestChunk[id])
+		ranked = append(ranked, *r)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Note.ModifiedAt.After(ranked[j].Note.ModifiedAt)
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	return ranked, nil
+}
+
+// queryTerms splits a query into lowercased words worth highlighting.
+func queryTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	var terms []string
+	seen := map[string]bool{}
+	for _, w := range words {
+		if len([]rune(w)) > 1 && !seen[w] {
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// wordChars are the letters, digits, combining marks and underscore of any
+// script. RE2's \b and \w only know ASCII, so they would never match
+// around a Cyrillic or Greek term.
+const wordChars = `\p{L}\p{N}\p{M}_`
+
+// highlight picks a window of content around the first query term (or the
+// best matching chunk when no term occurs verbatim) and wraps every term
+// occurrence in <mark>. The text is HTML-escaped so the snippet is safe to
+// render as HTML.
+func highlight(content string, terms []string, chunk ChunkHit) string {
+	var pattern *regexp.Regexp
+	if len(terms) > 0 {
+		quoted := make([]string, len(terms))
+		for i, t := range terms {
+			quoted[i] = regexp.QuoteMeta(t)
+		}
+		// The term is submatch 1; the prefix only anchors it at the start
+		// of a word.
+		pattern = regexp.MustCompile(`(?i)(?:^|[^` + wordChars + `])((?:` + strings.Join(quoted, "|") + `)[` + wordChars + `]*)`)
+	}
+
+	center := -1
+	if pattern != nil {
+		if loc := pattern.FindStringSubmatchIndex(content); loc != nil {
+			center = loc[2]
+		}
+	}
+	if center < 0 && chunk.End > chunk.Start && chunk.End <= len(content) {
+		center = chunk.Start
+	}
+	if center < 0 {
+		center = 0
+	}
+
+	start, end := snippetBounds(content, center)
+	window := content[start:end]
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("…")
+	}
+	last := 0
+	if pattern != nil {
+		for _, loc := range pattern.FindAllStringSubmatchIndex(window, -1) {
+			sb.WriteString(html.EscapeString(window[last:loc[2]]))
+			sb.WriteString("<mark>")
+			sb.WriteString(html.EscapeString(window[loc[2]:loc[3]]))
+			sb.WriteString("</mark>")
+			last = loc[3]
+		}
+	}
+	sb.WriteString(html.EscapeString(window[last:]))
+	if end < len(content) {
+		sb.WriteString("…")
+	}
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// snippetBounds returns a window of about snippetRadius bytes on each side
+// of center, widened to whitespace so words aren't cut in half. Both ends
+// fall on rune boundaries even when there is no whitespace to snap to.
+func snippetBounds(content string, center int) (int, int) {
+	start := tokenizer.RuneBoundary(content, max(center-snippetRadius, 0))
+	end := tokenizer.RuneBoundary(content, min(center+snippetRadius, len(content)))
+
+	if start > 0 {
+		if i := strings.IndexAny(content[start:center], " \t\n"); i >= 0 {
+			start += i + 1
+		}
+	}
+	if end < len(content) {
+		if i := strings.LastIndexAny(content[center:end], " \t\n"); i > 0 {
+			end = center + i
+		}
+	}
+	return start, end
+}
@@ -0,0 +1,92 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"note-llm/internal/chunking"
+	"note-llm/internal/models"
+	"note-llm/internal/repository"
+	"note-llm/internal/vectorstore"
+
+	"github.com/spf13/viper"
+)
+
+// memoryStores indexes notes for user u1 with the offline hash embedder.
+func memoryStores(t *testing.T, notes ...models.Note) Stores {
+	t.Helper()
+	viper.Set("EMBEDDING_PROVIDER", "hash")
+
+	vectors, err := vectorstore.OpenLocal("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := repository.NewMemoryNotes(vectors, chunking.Options{MaxTokens: 200})
+	for i, note := range notes {
+		note.UserID = "u1"
+		note.CreatedAt = time.Date(2024, 1, 1+i, 0, 0, 0, 0, time.UTC)
+		note.ModifiedAt = note.CreatedAt
+		if err := repo.Create(context.Background(), note); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return Stores{Notes: repo, Vectors: vectors}
+}
+
+func TestHybridSearchFusesRanks(t *testing.T) {
+	stores := memoryStores(t,
+		models.Note{ID: "both", Title: "Rye sourdough", Content: "Rye sourdough needs a long, cool proof."},
+		models.Note{ID: "starter", Title: "Starter", Content: "Feed the sourdough starter twice a day."},
+		models.Note{ID: "soup", Title: "Soup", Content: "Tomato soup with basil."},
+	)
+
+	results, err := HybridSearch(context.Background(), stores, "u1", "rye sourdough", Options{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 || results[0].Note.ID != "both" {
+		t.Fatalf("results = %+v, want the note matching both terms first", results)
+	}
+
+	k := float64(viper.GetInt("SEARCH_RRF_K"))
+	for i, r := range results {
+		var want float64
+		if r.TextRank > 0 {
+			want += 1 / (k + float64(r.TextRank))
+		}
+		if r.VectorRank > 0 {
+			want += 1 / (k + float64(r.VectorRank))
+		}
+		if r.Score != want {
+			t.Errorf("%s: score %v, want %v from ranks %d/%d", r.Note.ID, r.Score, want, r.TextRank, r.VectorRank)
+		}
+		if i > 0 && r.Score > results[i-1].Score {
+			t.Errorf("%s ranked below a lower score", r.Note.ID)
+		}
+	}
+	if r := results[0]; r.TextRank != 1 || r.VectorRank == 0 || !strings.Contains(r.Snippet, "<mark>") {
+		t.Errorf("top result = %+v", r)
+	}
+}
+
+func TestHighlightNonLatin(t *testing.T) {
+	content := "Тесто для ржаного хлеба: мука, вода и закваска."
+	got := highlight(content, queryTerms("ржаного ЗАКВАСКА"), ChunkHit{})
+	for _, want := range []string{"<mark>ржаного</mark>", "<mark>закваска</mark>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("highlight = %q, missing %q", got, want)
+		}
+	}
+}
+
+func TestSnippetBoundsStayOnRunes(t *testing.T) {
+	content := strings.Repeat("é", 300)
+	for _, center := range []int{0, 1, 151, 301, len(content) - 1} {
+		start, end := snippetBounds(content, center)
+		if !strings.HasPrefix(content[start:], "é") || (end < len(content) && !strings.HasPrefix(content[end:], "é")) {
+			t.Errorf("center %d: bounds [%d:%d] split a character", center, start, end)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package search
+
+import (
+	"math"
+)
+
+// mmrCandidateFactor is how many candidates are fetched per requested
+// result when MMR reranking is on.
+const mmrCandidateFactor = 4
+
+// mmr greedily picks k hits, each time taking the candidate that maximizes
+// lambda*relevance - (1-lambda)*(max similarity to anything already
+// picked). Candidates must carry their vectors.
+func mmr(candidates []ChunkHit, k int, lambda float64) []ChunkHit {
+	if k >= len(candidates) && lambda >= 1 {
+		return candidates
+	}
+
+	remaining := append([]ChunkHit(nil), candidates...)
+	selected := make([]ChunkHit, 0, min(k, len(candidates)))
+	// maxSim[i] is the highest similarity of remaining[i] to the selection.
+	maxSim := make([]float64, len(remaining))
+
+	for len(selected) < k && len(remaining) > 0 {
+		best, bestScore := 0, math.Inf(-1)
+		for i, c := range remaining {
+			score := lambda*float64(c.Score) - (1-lambda)*maxSim[i]
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		picked := remaining[best]
+		selected = append(selected, picked)
+		remaining = append(remaining[:best], remaining[best+1:]...)
+		maxSim = append(maxSim[:best], maxSim[best+1:]...)
+
+		for i, c := range remaining {
+			if sim := cosine(c.Vector, picked.Vector); sim > maxSim[i] {
+				maxSim[i] = sim
+			}
+		}
+	}
+
+	return selected
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,46 @@
+package search
+
+import (
+	"testing"
+)
+
+func TestMMRPrefersDiverseHits(t *testing.T) {
+	candidates := []ChunkHit{
+		{NoteID: "a", Score: 0.9, Vector: []float32{1, 0}},
+		{NoteID: "a-copy", Score: 0.89, Vector: []float32{1, 0}},
+		{NoteID: "b", Score: 0.8, Vector: []float32{0, 1}},
+	}
+
+	got := mmr(candidates, 2, 0.5)
+	if len(got) != 2 || got[0].NoteID != "a" || got[1].NoteID != "b" {
+		t.Fatalf("mmr picked %v, want a then b", ids(got))
+	}
+
+	// With lambda 1 only relevance counts.
+	got = mmr(candidates, 2, 1)
+	if len(got) != 2 || got[1].NoteID != "a-copy" {
+		t.Fatalf("mmr with lambda 1 picked %v", ids(got))
+	}
+
+	// With lambda 0 only diversity counts: after the first pick, the
+	// duplicate loses even to an orthogonal hit.
+	got = mmr(candidates, 3, 0)
+	if len(got) != 3 || got[1].NoteID != "b" || got[2].NoteID != "a-copy" {
+		t.Fatalf("mmr with lambda 0 picked %v", ids(got))
+	}
+}
+
+func TestMMRFewerCandidatesThanK(t *testing.T) {
+	candidates := []ChunkHit{{NoteID: "a", Score: 1, Vector: []float32{1}}}
+	if got := mmr(candidates, 5, 0.5); len(got) != 1 {
+		t.Fatalf("got %d hits, want 1", len(got))
+	}
+}
+
+func ids(hits []ChunkHit) []string {
+	out := make([]string, len(hits))
+	for i, h := range hits {
+		out[i] = h.NoteID
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package search
+
+import (
+	"fmt"
+
+	"note-llm/internal/models"
+
+	"github.com/spf13/viper"
+)
+
+// MaxLimit caps how many chunks a single search may return.
+const MaxLimit = 100
+
+// Options tune vector retrieval. Zero and nil values fall back to the
+// configured defaults (SEARCH_LIMIT, SEARCH_MIN_SCORE, SEARCH_MMR,
+// SEARCH_MMR_LAMBDA).
+type Options struct {
+	// Limit is the number of chunks to return.
+	Limit int `json:"limit,omitempty"`
+	// MinScore drops hits with a lower similarity score. It is a pointer
+	// so that a request can turn a configured threshold off with 0.
+	MinScore *float32 `json:"min_score,omitempty"`
+	// MMR reranks candidates with maximal marginal relevance, trading some
+	// similarity for less redundant results. It is a pointer so that a
+	// request can turn it off when SEARCH_MMR is on.
+	MMR *bool `json:"mmr,omitempty"`
+	// MMRLambda weighs relevance against diversity, from 0 (only
+	// diversity) to 1 (only relevance). It is a pointer so that 0 can be
+	// told apart from unset.
+	MMRLambda *float64 `json:"mmr_lambda,omitempty"`
+	// NoteFilter restricts which notes are searched.
+	models.NoteFilter
+}
+
+func init() {
+	viper.SetDefault("SEARCH_LIMIT", 8)
+	viper.SetDefault("SEARCH_MIN_SCORE", 0)
+	viper.SetDefault("SEARCH_MMR", false)
+	viper.SetDefault("SEARCH_MMR_LAMBDA", 0.7)
+}
+
+// WithDefaults fills unset fields from config.
+func (o Options) WithDefaults() Options {
+	if o.Limit == 0 {
+		o.Limit = viper.GetInt("SEARCH_LIMIT")
+	}
+	if o.MinScore == nil {
+		minScore := float32(viper.GetFloat64("SEARCH_MIN_SCORE"))
+		o.MinScore = &minScore
+	}
+	if o.MMR == nil {
+		mmr := viper.GetBool("SEARCH_MMR")
+		o.MMR = &mmr
+	}
+	if o.MMRLambda == nil {
+		lambda := viper.GetFloat64("SEARCH_MMR_LAMBDA")
+		o.MMRLambda = &lambda
+	}
+	return o
+}
+
+// Validate rejects out-of-range values from API callers.
+func (o Options) Validate() error {
+	if o.Limit < 0 || o.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+	}
+	if o.MinScore != nil && (*o.MinScore < -1 || *o.MinScore > 1) {
+		return fmt.Errorf("min_score must be between -1 and 1")
+	}
+	if o.MMRLambda != nil && (*o.MMRLambda < 0 || *o.MMRLambda > 1) {
+		return fmt.Errorf("mmr_lambda must be between 0 and 1")
+	}
+	return o.NoteFilter.Validate()
+}
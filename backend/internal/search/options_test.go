@@ -0,0 +1,21 @@
+package search
+
+import (
+	"testing"
+)
+
+func TestWithDefaultsKeepsExplicitZeros(t *testing.T) {
+	off, zero, none := false, 0.0, float32(0)
+	opts := Options{MMR: &off, MMRLambda: &zero, MinScore: &none}.WithDefaults()
+	if *opts.MMR || *opts.MMRLambda != 0 || *opts.MinScore != 0 {
+		t.Errorf("explicit zeros replaced: mmr=%v lambda=%v min_score=%v", *opts.MMR, *opts.MMRLambda, *opts.MinScore)
+	}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	opts = Options{}.WithDefaults()
+	if opts.Limit != 8 || opts.MMR == nil || opts.MMRLambda == nil || *opts.MMRLambda != 0.7 || opts.MinScore == nil {
+		t.Errorf("defaults not filled in: %+v", opts)
+	}
+}
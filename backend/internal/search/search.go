@@ -4,42 +4,69 @@ import (
 	"context"
 	"fmt"
 	"note-llm/internal/llm"
-	"note-llm/internal/qdrant"
-
-	qdrantpb "github.com/qdrant/go-client/qdrant"
+	"note-llm/internal/repository"
+	"note-llm/internal/vectorstore"
 )
 
-func SearchRelevantNotes(userID string, query string) ([]string, error) {
+// ChunkHit is a matching chunk of a note.
+type ChunkHit = vectorstore.Hit
+
+// Stores are the note and vector storage that searches read from.
+type Stores struct {
+	Notes   repository.NoteRepository
+	Vectors vectorstore.VectorStore
+}
+
+// SearchRelevantChunks returns the note chunks in vectors closest to query,
+// best first, as tuned by opts.
+func SearchRelevantChunks(ctx context.Context, vectors vectorstore.VectorStore, userID string, query string, opts Options) ([]ChunkHit, error) {
+	opts = opts.WithDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	diversify := *opts.MMR
+	limit := opts.Limit
+	if diversify {
+		limit *= mmrCandidateFactor
+	}
+
 	// Step 1: Embed the query
-	embeddings, err := llm.GetEmbeddings([]string{query})
+	embeddings, err := llm.GetEmbeddings(ctx, []string{query})
 	if err != nil {
 		return nil, fmt.Errorf("failed to embed query: %w", err)
 	}
 
-	// Step 2: Search Qdrant for similar notes
-	ctx := context.Background()
-	resp, err := qdrant.GetQdrantClient().Query(ctx, &qdrantpb.QueryPoints{
-		CollectionName: "notes",
-		Query:          qdrantpb.NewQuery(embeddings[0]...),
-		Filter: &qdrantpb.Filter{
-			Must: []*qdrantpb.Condition{
-				qdrantpb.NewMatch("user_id", userID),
-			},
-		},
-		WithPayload: qdrantpb.NewWithPayload(true),
+	// Step 2: Search the vector store for similar chunks
+	hits, err := vectors.Query(ctx, vectorstore.Query{
+		UserID:      userID,
+		Vector:      embeddings[0],
+		Filter:      opts.NoteFilter,
+		Limit:       limit,
+		MinScore:    *opts.MinScore,
+		WithVectors: diversify,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("qdrant search error: %w", err)
+		return nil, fmt.Errorf("vector search error: %w", err)
+	}
+
+	// Step 3: Diversify
+	if diversify {
+		hits = mmr(hits, opts.Limit, *opts.MMRLambda)
 	}
 
-	// Step 3: Extract note IDs or payloads
+	return hits, nil
+}
+
+// NoteIDs returns the distinct note IDs of hits in rank order.
+func NoteIDs(hits []ChunkHit) []string {
+	seen := make(map[string]bool, len(hits))
 	var notes []string
-	for _, hit := range resp {
-		payload := hit.GetPayload()
-		if payload["note_id"] != nil {
-			notes = append(notes, payload["note_id"].GetStringValue())
+	for _, hit := range hits {
+		if !seen[hit.NoteID] {
+			seen[hit.NoteID] = true
+			notes = append(notes, hit.NoteID)
 		}
 	}
-
-	return notes, nil
+	return notes
 }
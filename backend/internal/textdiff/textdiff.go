@@ -0,0 +1,106 @@
+package textdiff
+
+import (
+	"strings"
+)
+
+// Line operations.
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// maxCells bounds the LCS table (lines of a × lines of b, after the common
+// prefix and suffix are removed). Beyond it the changed middle is reported
+// as deleted and re-inserted wholesale.
+const maxCells = 4_000_000
+
+// Line is one line of a diff; Op is one of the Op constants.
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines returns a line-level diff turning a into b: a longest common
+// subsequence of lines is kept as "equal", the rest of a is deleted and
+// the rest of b inserted.
+func Lines(a, b string) []Line {
+	x, y := splitLines(a), splitLines(b)
+
+	// Common prefix and suffix need no table.
+	pre := 0
+	for pre < len(x) && pre < len(y) && x[pre] == y[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(x)-pre && suf < len(y)-pre && x[len(x)-1-suf] == y[len(y)-1-suf] {
+		suf++
+	}
+
+	var out []Line
+	for _, l := range x[:pre] {
+		out = append(out, Line{Op: OpEqual, Text: l})
+	}
+	out = append(out, middle(x[pre:len(x)-suf], y[pre:len(y)-suf])...)
+	for _, l := range x[len(x)-suf:] {
+		out = append(out, Line{Op: OpEqual, Text: l})
+	}
+	return out
+}
+
+func middle(x, y []string) []Line {
+	var out []Line
+	if len(x)*len(y) > maxCells {
+		for _, l := range x {
+			out = append(out, Line{Op: OpDelete, Text: l})
+		}
+		for _, l := range y {
+			out = append(out, Line{Op: OpInsert, Text: l})
+		}
+		return out
+	}
+
+	// lcs[i][j] is the LCS length of x[i:] and y[j:].
+	cols := len(y) + 1
+	lcs := make([]int32, (len(x)+1)*cols)
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i*cols+j] = lcs[(i+1)*cols+j+1] + 1
+			} else {
+				lcs[i*cols+j] = max(lcs[(i+1)*cols+j], lcs[i*cols+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			out = append(out, Line{Op: OpEqual, Text: x[i]})
+			i++
+			j++
+		case lcs[(i+1)*cols+j] >= lcs[i*cols+j+1]:
+			out = append(out, Line{Op: OpDelete, Text: x[i]})
+			i++
+		default:
+			out = append(out, Line{Op: OpInsert, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		out = append(out, Line{Op: OpDelete, Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		out = append(out, Line{Op: OpInsert, Text: y[j]})
+	}
+	return out
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,46 @@
+package textdiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	got := Lines("a\nb\nc\nd", "a\nc\nx\nd")
+	want := []Line{
+		{OpEqual, "a"},
+		{OpDelete, "b"},
+		{OpEqual, "c"},
+		{OpInsert, "x"},
+		{OpEqual, "d"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Lines = %v, want %v", got, want)
+	}
+}
+
+func TestLinesIdentical(t *testing.T) {
+	for _, l := range Lines("one\ntwo", "one\ntwo") {
+		if l.Op != OpEqual {
+			t.Fatalf("identical texts gave %v", l)
+		}
+	}
+}
+
+// Applying the diff must rebuild both sides.
+func TestLinesRoundTrip(t *testing.T) {
+	a := "title\n\nfirst\nsecond\nthird\n"
+	b := "title\nsecond\nnew\nthird\nlast\n"
+	var left, right []string
+	for _, l := range Lines(a, b) {
+		if l.Op != OpInsert {
+			left = append(left, l.Text)
+		}
+		if l.Op != OpDelete {
+			right = append(right, l.Text)
+		}
+	}
+	if !reflect.DeepEqual(left, splitLines(a)) || !reflect.DeepEqual(right, splitLines(b)) {
+		t.Fatalf("diff does not rebuild inputs: %v / %v", left, right)
+	}
+}
@@ -0,0 +1,124 @@
+package tokenizer
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+	"github.com/spf13/viper"
+)
+
+// Span is a token's byte range [Start, End) in the text it came from.
+type Span struct {
+	Start int
+	End   int
+}
+
+var (
+	encoding     *tiktoken.Tiktoken
+	encodingOnce sync.Once
+	encodingErr  error
+
+	// fallbackToken approximates BPE tokens when TOKENIZER_ENCODING names
+	// an encoding tiktoken doesn't know: a run of word characters or a
+	// single other non-space character, plus trailing whitespace.
+	fallbackToken = regexp.MustCompile(`(?:[\p{L}\p{N}]{1,4}|[^\s\p{L}\p{N}])\s*`)
+)
+
+func init() {
+	viper.SetDefault("TOKENIZER_ENCODING", "cl100k_base")
+	// The ranks are embedded in the binary rather than downloaded on first
+	// use, so counts are the same with or without network access.
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// Init loads the configured encoding and logs when token counts will only
+// be approximate. Calling it at startup surfaces a bad TOKENIZER_ENCODING
+// right away instead of on the first indexed note.
+func Init() {
+	getEncoding()
+}
+
+func getEncoding() (*tiktoken.Tiktoken, error) {
+	encodingOnce.Do(func() {
+		name := viper.GetString("TOKENIZER_ENCODING")
+		encoding, encodingErr = tiktoken.GetEncoding(name)
+		if encodingErr != nil {
+			fmt.Printf("Tokenizer %s unavailable, using approximate counts: %v\n", name, encodingErr)
+		}
+	})
+	return encoding, encodingErr
+}
+
+// Count returns the number of tokens in text.
+func Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	if enc, err := getEncoding(); err == nil {
+		return len(enc.Encode(text, nil, nil))
+	}
+	return len(fallbackToken.FindAllStringIndex(text, -1))
+}
+
+// Spans splits text into tokens and returns their byte offsets. Joining
+// text[s.Start:s.End] for every span reproduces the original text.
+func Spans(text string) []Span {
+	if text == "" {
+		return nil
+	}
+
+	if enc, err := getEncoding(); err == nil {
+		tokens := enc.Encode(text, nil, nil)
+		spans := make([]Span, 0, len(tokens))
+		offset := 0
+		for _, tok := range tokens {
+			// Decoding a single token yields its exact bytes, even when it
+			// only holds part of a multi-byte character.
+			n := len(enc.Decode([]int{tok}))
+			spans = append(spans, Span{Start: offset, End: offset + n})
+			offset += n
+		}
+		return spans
+	}
+
+	matches := fallbackToken.FindAllStringIndex(text, -1)
+	spans := make([]Span, 0, len(matches))
+	offset := 0
+	for _, m := range matches {
+		// Fold any leading whitespace the pattern skipped into this span.
+		spans = append(spans, Span{Start: offset, End: m[1]})
+		offset = m[1]
+	}
+	if offset < len(text) && len(spans) > 0 {
+		spans[len(spans)-1].End = len(text)
+	}
+	return spans
+}
+
+// Truncate cuts text down to at most maxTokens tokens.
+func Truncate(text string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+	spans := Spans(text)
+	if len(spans) <= maxTokens {
+		return text
+	}
+	return text[:RuneBoundary(text, spans[maxTokens-1].End)]
+}
+
+// RuneBoundary moves a byte offset back to the start of the UTF-8 character
+// it falls in, so slicing at it never splits a character.
+func RuneBoundary(text string, offset int) int {
+	if offset >= len(text) {
+		return len(text)
+	}
+	for offset > 0 && !utf8.RuneStart(text[offset]) {
+		offset--
+	}
+	return offset
+}
@@ -0,0 +1,27 @@
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+)
+
+// The ranks ship with the binary, so the exact encoding is always there.
+func TestEncodingIsEmbedded(t *testing.T) {
+	if _, err := getEncoding(); err != nil {
+		t.Fatalf("encoding unavailable: %v", err)
+	}
+}
+
+func TestSpansCoverText(t *testing.T) {
+	text := "Crème brûlée — 焼き菓子 🍞 rye"
+	var b strings.Builder
+	for _, s := range Spans(text) {
+		b.WriteString(text[s.Start:s.End])
+	}
+	if b.String() != text {
+		t.Fatalf("spans rebuild %q, want %q", b.String(), text)
+	}
+	if got := Truncate(text, 3); !strings.HasPrefix(text, got) || Count(got) > 3 {
+		t.Errorf("Truncate(3) = %q", got)
+	}
+}
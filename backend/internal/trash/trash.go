@@ -0,0 +1,70 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"note-llm/internal/repository"
+)
+
+// purgeBatchSize is how many expired notes are deleted per round.
+const purgeBatchSize = 100
+
+// PurgeExpired permanently deletes notes that have been in the trash for
+// longer than retention, with their revisions and vectors, and returns how
+// many were deleted. Each goes through notes.Purge, so its vectors are
+// removed like those of a note purged by hand.
+func PurgeExpired(ctx context.Context, notes repository.NoteRepository, retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention)
+	purged := 0
+
+	for {
+		expired, err := notes.ExpiredTrash(ctx, cutoff, purgeBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("failed to find expired notes: %w", err)
+		}
+		if len(expired) == 0 {
+			return purged, nil
+		}
+
+		for _, note := range expired {
+			err := notes.Purge(ctx, note.ID, note.UserID, note.Version)
+			// Restored or purged by its owner since it was found.
+			if errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrVersionConflict) {
+				continue
+			}
+			if err != nil {
+				return purged, fmt.Errorf("failed to delete note %s: %w", note.ID, err)
+			}
+			purged++
+		}
+
+		if len(expired) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// RunPurger empties expired trash from notes once at start and then every
+// interval until ctx is cancelled.
+func RunPurger(ctx context.Context, notes repository.NoteRepository, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := PurgeExpired(ctx, notes, retention)
+		if err != nil {
+			fmt.Printf("Trash purge error: %v\n", err)
+		} else if purged > 0 {
+			fmt.Printf("Trash purge: deleted %d notes\n", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package trash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-llm/internal/chunking"
+	"note-llm/internal/models"
+	"note-llm/internal/repository"
+)
+
+func TestPurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	notes := repository.NewMemoryNotes(nil, chunking.Options{})
+	for _, id := range []string{"kept", "trashed"} {
+		if err := notes.Create(ctx, models.Note{ID: id, UserID: "u1", Title: id, Version: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := notes.Trash(ctx, "trashed", "u1", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := PurgeExpired(ctx, notes, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 0 {
+		t.Fatalf("purged %d notes trashed within retention, want 0", purged)
+	}
+
+	purged, err = PurgeExpired(ctx, notes, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("purged %d notes, want 1", purged)
+	}
+	trashed, err := notes.ListTrash(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 0 {
+		t.Fatalf("trash = %+v, want it empty", trashed)
+	}
+	if note, err := notes.Get(ctx, "kept", "u1"); err != nil || note == nil {
+		t.Fatalf("Get(kept) = %v, %v; want the note outside the trash kept", note, err)
+	}
+}
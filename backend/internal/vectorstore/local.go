@@ -0,0 +1,310 @@
+package vectorstore
+
+import (
+	"cmp"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"note-llm/internal/models"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	Register("local", func() (VectorStore, error) {
+		viper.SetDefault("VECTOR_STORE_PATH", "data/vectors.gob")
+		return OpenLocal(viper.GetString("VECTOR_STORE_PATH"))
+	})
+}
+
+// localPoint is one chunk vector with its note's metadata.
+type localPoint struct {
+	NoteID     string
+	UserID     string
+	NotebookID string
+	Tags       []string
+	CreatedAt  time.Time
+	ModifiedAt time.Time
+	Version    int64
+	ChunkIndex int
+	Start      int
+	End        int
+	Vector     []float32
+}
+
+// matches reports whether the point belongs to userID and its note passes
+// f.
+func (p localPoint) matches(userID string, f models.NoteFilter) bool {
+	return p.UserID == userID && f.Matches(models.Note{
+		ID:         p.NoteID,
+		NotebookID: p.NotebookID,
+		Tags:       p.Tags,
+		CreatedAt:  p.CreatedAt,
+		ModifiedAt: p.ModifiedAt,
+	})
+}
+
+// Local keeps every point in memory and searches them by brute force,
+// which stays fast up to some tens of thousands of chunks. Each write
+// rewrites the whole file at path, so it suits small, single-process
+// deployments; an empty path keeps the points in memory only.
+type Local struct {
+	mu   sync.RWMutex
+	path string
+	// points holds each note's points in chunk order.
+	points map[string][]localPoint
+}
+
+// OpenLocal loads the store saved at path, starting empty if there is no
+// file yet.
+func OpenLocal(path string) (*Local, error) {
+	s := &Local{path: path, points: map[string][]localPoint{}}
+	if path == "" {
+		return s, nil
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := gob.NewDecoder(f).Decode(&s.points); err != nil {
+		return nil, fmt.Errorf("failed to read vector store %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// save writes all points to a temporary file and renames it over the
+// store, so a crash never leaves a half-written file. Callers hold mu.
+func (s *Local) save() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(s.points); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *Local) Upsert(ctx context.Context, note NotePayload, chunks []ChunkPoint) error {
+	points := make([]localPoint, len(chunks))
+	for i, c := range chunks {
+		points[i] = localPoint{
+			NoteID:     note.NoteID,
+			UserID:     note.UserID,
+			NotebookID: note.NotebookID,
+			Tags:       note.Tags,
+			CreatedAt:  note.CreatedAt,
+			ModifiedAt: note.ModifiedAt,
+			Version:    note.Version,
+			ChunkIndex: c.Index,
+			Start:      c.Start,
+			End:        c.End,
+			Vector:     c.Vector,
+		}
+	}
+	slices.SortFunc(points, func(a, b localPoint) int { return cmp.Compare(a.ChunkIndex, b.ChunkIndex) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(points) == 0 {
+		delete(s.points, note.NoteID)
+	} else {
+		s.points[note.NoteID] = points
+	}
+	return s.save()
+}
+
+func (s *Local) Delete(ctx context.Context, noteIDs ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for _, id := range noteIDs {
+		if _, ok := s.points[id]; ok {
+			delete(s.points, id)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.save()
+}
+
+// defaultQueryLimit is how many hits Query returns for a zero Limit, the
+// same as Qdrant.
+const defaultQueryLimit = 10
+
+// Query scores every point of the user by cosine similarity. A zero vector
+// is similar to nothing, so it matches no points.
+func (s *Local) Query(ctx context.Context, q Query) ([]Hit, error) {
+	norm := vectorNorm(q.Vector)
+	if norm == 0 {
+		return nil, nil
+	}
+	limit := q.Limit
+	if limit == 0 {
+		limit = defaultQueryLimit
+	}
+
+	s.mu.RLock()
+	var hits []Hit
+	for _, points := range s.points {
+		for _, p := range points {
+			if !p.matches(q.UserID, q.Filter) {
+				continue
+			}
+			score := cosine(q.Vector, norm, p.Vector)
+			if q.MinScore != 0 && score < q.MinScore {
+				continue
+			}
+			hit := Hit{
+				NoteID:      p.NoteID,
+				NoteVersion: p.Version,
+				ChunkIndex:  p.ChunkIndex,
+				Start:       p.Start,
+				End:         p.End,
+				Score:       score,
+			}
+			if q.WithVectors {
+				hit.Vector = p.Vector
+			}
+			hits = append(hits, hit)
+		}
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(hits, func(a, b Hit) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.NoteID, b.NoteID), cmp.Compare(a.ChunkIndex, b.ChunkIndex))
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func (s *Local) Count(ctx context.Context, userID string, f models.NoteFilter) (uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var n uint64
+	for _, points := range s.points {
+		for _, p := range points {
+			if p.matches(userID, f) {
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+func (s *Local) ClearNotebook(ctx context.Context, userID, notebookID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for _, points := range s.points {
+		for i := range points {
+			if points[i].UserID == userID && points[i].NotebookID == notebookID {
+				points[i].NotebookID = ""
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.save()
+}
+
+func (s *Local) NoteChunkVectors(ctx context.Context, userID string, noteIDs []string) (map[string][][]float32, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	vectors := map[string][][]float32{}
+	for _, id := range noteIDs {
+		for _, p := range s.points[id] {
+			if p.UserID == userID {
+				vectors[id] = append(vectors[id], p.Vector)
+			}
+		}
+	}
+	return vectors, nil
+}
+
+func (s *Local) ScrollNoteIDs(ctx context.Context, pageSize int, fn func(noteIDs []string) error) error {
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.points))
+	for id := range s.points {
+		ids = append(ids, id)
+	}
+	s.mu.RUnlock()
+
+	// fn may delete points, so it runs without the lock.
+	for page := range slices.Chunk(ids, max(pageSize, 1)) {
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScrollLegacyNoteIDs finds nothing: the local store came after chunking,
+// so it never held whole-note points.
+func (s *Local) ScrollLegacyNoteIDs(ctx context.Context, pageSize int, fn func(noteIDs []string) error) error {
+	return nil
+}
+
+func vectorNorm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+// cosine is the cosine similarity of q, whose norm is qNorm, and v.
+// Vectors of a different dimension score 0.
+func cosine(q []float32, qNorm float64, v []float32) float32 {
+	if len(v) != len(q) {
+		return 0
+	}
+	var dot float64
+	for i := range q {
+		dot += float64(q[i]) * float64(v[i])
+	}
+	vNorm := vectorNorm(v)
+	if vNorm == 0 {
+		return 0
+	}
+	return float32(dot / (qNorm * vNorm))
+}
@@ -0,0 +1,43 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestLocalQuery(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenLocal("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range defaultQueryLimit + 2 {
+		note := NotePayload{NoteID: fmt.Sprintf("n%02d", i), UserID: "u1"}
+		chunks := []ChunkPoint{{End: 1, Vector: []float32{1, float32(i)}}}
+		if err := store.Upsert(ctx, note, chunks); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hits, err := store.Query(ctx, Query{UserID: "u1", Vector: []float32{1, 0}, Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 3 || hits[0].NoteID != "n00" || hits[1].NoteID != "n01" {
+		t.Fatalf("hits = %+v, want the 3 closest notes", hits)
+	}
+
+	hits, err = store.Query(ctx, Query{UserID: "u1", Vector: []float32{1, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != defaultQueryLimit {
+		t.Errorf("got %d hits without a limit, want %d", len(hits), defaultQueryLimit)
+	}
+
+	hits, err = store.Query(ctx, Query{UserID: "u1", Vector: []float32{0, 0}, Limit: 3})
+	if err != nil || len(hits) != 0 {
+		t.Errorf("zero vector: hits = %+v, err = %v; want none", hits, err)
+	}
+}
@@ -0,0 +1,353 @@
+package vectorstore
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"note-llm/internal/models"
+	"note-llm/internal/qdrant"
+
+	"github.com/google/uuid"
+	qdrantpb "github.com/qdrant/go-client/qdrant"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func init() {
+	Register("qdrant", func() (VectorStore, error) { return Qdrant{}, nil })
+}
+
+// qdrantCollection holds one point per note chunk.
+const qdrantCollection = "notes"
+
+// Qdrant stores vectors in the "notes" collection of the Qdrant server
+// configured by QDRANT_HOST and QDRANT_API.
+type Qdrant struct{}
+
+// ChunkPointID derives a stable point ID for a note chunk, so re-indexing
+// a note overwrites its previous points instead of duplicating them.
+func ChunkPointID(noteID string, index int) string {
+	namespace, err := uuid.Parse(noteID)
+	if err != nil {
+		namespace = uuid.NewSHA1(uuid.NameSpaceOID, []byte(noteID))
+	}
+	return uuid.NewSHA1(namespace, []byte(strconv.Itoa(index))).String()
+}
+
+func (Qdrant) Upsert(ctx context.Context, note NotePayload, chunks []ChunkPoint) error {
+	now := time.Now().Format(time.RFC3339)
+	noteID := note.NoteID
+
+	tags := &qdrantpb.ListValue{Values: make([]*qdrantpb.Value, len(note.Tags))}
+	for i, tag := range note.Tags {
+		tags.Values[i] = qdrantpb.NewValueString(tag)
+	}
+
+	points := make([]*qdrantpb.PointStruct, 0, len(chunks))
+	ids := make([]*qdrantpb.PointId, 0, len(chunks))
+	for _, c := range chunks {
+		id := qdrantpb.NewIDUUID(ChunkPointID(noteID, c.Index))
+		ids = append(ids, id)
+		payload := map[string]*qdrantpb.Value{
+			"user_id":      qdrantpb.NewValueString(note.UserID),
+			"note_id":      qdrantpb.NewValueString(noteID),
+			"tags":         qdrantpb.NewValueList(tags),
+			"chunk_index":  qdrantpb.NewValueInt(int64(c.Index)),
+			"start_offset": qdrantpb.NewValueInt(int64(c.Start)),
+			"end_offset":   qdrantpb.NewValueInt(int64(c.End)),
+			"note_version": qdrantpb.NewValueInt(note.Version),
+			"created_at":   qdrantpb.NewValueString(note.CreatedAt.UTC().Format(time.RFC3339)),
+			"modified_at":  qdrantpb.NewValueString(note.ModifiedAt.UTC().Format(time.RFC3339)),
+			"indexed_at":   qdrantpb.NewValueString(now),
+		}
+		if note.NotebookID != "" {
+			payload["notebook_id"] = qdrantpb.NewValueString(note.NotebookID)
+		}
+		points = append(points, &qdrantpb.PointStruct{
+			Id:      id,
+			Vectors: qdrantpb.NewVectorsDense(c.Vector),
+			Payload: payload,
+		})
+	}
+
+	client, err := qdrant.GetQdrantClient()
+	if err != nil {
+		return err
+	}
+	if len(points) > 0 {
+		_, err := client.Upsert(ctx, &qdrantpb.UpsertPoints{
+			CollectionName: qdrantCollection,
+			Wait:           qdrantpb.PtrOf(true),
+			Points:         points,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Also drops the legacy single point per note.
+	stale := &qdrantpb.Filter{
+		Must: []*qdrantpb.Condition{qdrantpb.NewMatch("note_id", noteID)},
+	}
+	if len(ids) > 0 {
+		stale.MustNot = []*qdrantpb.Condition{qdrantpb.NewHasID(ids...)}
+	}
+	_, err = client.Delete(ctx, &qdrantpb.DeletePoints{
+		CollectionName: qdrantCollection,
+		Points:         qdrantpb.NewPointsSelectorFilter(stale),
+	})
+	return err
+}
+
+func (Qdrant) Delete(ctx context.Context, noteIDs ...string) error {
+	if len(noteIDs) == 0 {
+		return nil
+	}
+
+	client, err := qdrant.GetQdrantClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.Delete(ctx, &qdrantpb.DeletePoints{
+		CollectionName: qdrantCollection,
+		Wait:           qdrantpb.PtrOf(true),
+		Points: qdrantpb.NewPointsSelectorFilter(&qdrantpb.Filter{
+			Must: []*qdrantpb.Condition{qdrantpb.NewMatchKeywords("note_id", noteIDs...)},
+		}),
+	})
+	return err
+}
+
+func (Qdrant) Query(ctx context.Context, q Query) ([]Hit, error) {
+	request := &qdrantpb.QueryPoints{
+		CollectionName: qdrantCollection,
+		Query:          qdrantpb.NewQuery(q.Vector...),
+		Filter:         userFilter(q.UserID, q.Filter),
+		WithPayload:    qdrantpb.NewWithPayload(true),
+		WithVectors:    qdrantpb.NewWithVectors(q.WithVectors),
+		Limit:          qdrantpb.PtrOf(uint64(q.Limit)),
+	}
+	if q.MinScore != 0 {
+		request.ScoreThreshold = qdrantpb.PtrOf(q.MinScore)
+	}
+	client, err := qdrant.GetQdrantClient()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Query(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []Hit
+	for _, point := range resp {
+		payload := point.GetPayload()
+		if payload["note_id"] == nil {
+			continue
+		}
+		hits = append(hits, Hit{
+			NoteID:      payload["note_id"].GetStringValue(),
+			NoteVersion: payload["note_version"].GetIntegerValue(),
+			ChunkIndex:  int(payload["chunk_index"].GetIntegerValue()),
+			Start:       int(payload["start_offset"].GetIntegerValue()),
+			End:         int(payload["end_offset"].GetIntegerValue()),
+			Score:       point.GetScore(),
+			Vector:      denseVector(point.GetVectors()),
+		})
+	}
+	return hits, nil
+}
+
+func (Qdrant) Count(ctx context.Context, userID string, f models.NoteFilter) (uint64, error) {
+	client, err := qdrant.GetQdrantClient()
+	if err != nil {
+		return 0, err
+	}
+	return client.Count(ctx, &qdrantpb.CountPoints{
+		CollectionName: qdrantCollection,
+		Filter:         userFilter(userID, f),
+		Exact:          qdrantpb.PtrOf(true),
+	})
+}
+
+func (Qdrant) ClearNotebook(ctx context.Context, userID, notebookID string) error {
+	client, err := qdrant.GetQdrantClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.DeletePayload(ctx, &qdrantpb.DeletePayloadPoints{
+		CollectionName: qdrantCollection,
+		Wait:           qdrantpb.PtrOf(true),
+		Keys:           []string{"notebook_id"},
+		PointsSelector: qdrantpb.NewPointsSelectorFilter(&qdrantpb.Filter{
+			Must: []*qdrantpb.Condition{
+				qdrantpb.NewMatch("user_id", userID),
+				qdrantpb.NewMatch("notebook_id", notebookID),
+			},
+		}),
+	})
+	return err
+}
+
+func (Qdrant) NoteChunkVectors(ctx context.Context, userID string, noteIDs []string) (map[string][][]float32, error) {
+	if len(noteIDs) == 0 {
+		return map[string][][]float32{}, nil
+	}
+
+	type chunk struct {
+		index  int64
+		vector []float32
+	}
+	chunks := map[string][]chunk{}
+
+	client, err := qdrant.GetQdrantClient()
+	if err != nil {
+		return nil, err
+	}
+	var offset *qdrantpb.PointId
+	for {
+		points, next, err := client.ScrollAndOffset(ctx, &qdrantpb.ScrollPoints{
+			CollectionName: qdrantCollection,
+			Filter: &qdrantpb.Filter{
+				Must: []*qdrantpb.Condition{
+					qdrantpb.NewMatch("user_id", userID),
+					qdrantpb.NewMatchKeywords("note_id", noteIDs...),
+				},
+			},
+			Offset:      offset,
+			Limit:       qdrantpb.PtrOf(uint32(256)),
+			WithPayload: qdrantpb.NewWithPayloadInclude("note_id", "chunk_index"),
+			WithVectors: qdrantpb.NewWithVectors(true),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range points {
+			payload := p.GetPayload()
+			noteID := payload["note_id"].GetStringValue()
+			chunks[noteID] = append(chunks[noteID], chunk{
+				index:  payload["chunk_index"].GetIntegerValue(),
+				vector: denseVector(p.GetVectors()),
+			})
+		}
+
+		if next == nil {
+			break
+		}
+		offset = next
+	}
+
+	vectors := make(map[string][][]float32, len(chunks))
+	for noteID, cs := range chunks {
+		sort.Slice(cs, func(i, j int) bool { return cs[i].index < cs[j].index })
+		for _, c := range cs {
+			vectors[noteID] = append(vectors[noteID], c.vector)
+		}
+	}
+	return vectors, nil
+}
+
+func (Qdrant) ScrollNoteIDs(ctx context.Context, pageSize int, fn func(noteIDs []string) error) error {
+	return scrollNoteIDs(ctx, nil, pageSize, fn)
+}
+
+func (Qdrant) ScrollLegacyNoteIDs(ctx context.Context, pageSize int, fn func(noteIDs []string) error) error {
+	legacy := &qdrantpb.Filter{
+		Should: []*qdrantpb.Condition{
+			qdrantpb.NewIsEmpty("chunk_index"),
+			qdrantpb.NewIsEmpty("note_version"),
+		},
+	}
+	return scrollNoteIDs(ctx, legacy, pageSize, fn)
+}
+
+// scrollNoteIDs walks the points that match filter, or all of them when
+// it is nil.
+func scrollNoteIDs(ctx context.Context, filter *qdrantpb.Filter, pageSize int, fn func(noteIDs []string) error) error {
+	client, err := qdrant.GetQdrantClient()
+	if err != nil {
+		return err
+	}
+
+	var offset *qdrantpb.PointId
+	for {
+		points, next, err := client.ScrollAndOffset(ctx, &qdrantpb.ScrollPoints{
+			CollectionName: qdrantCollection,
+			Filter:         filter,
+			Offset:         offset,
+			Limit:          qdrantpb.PtrOf(uint32(pageSize)),
+			WithPayload:    qdrantpb.NewWithPayloadInclude("note_id"),
+			WithVectors:    qdrantpb.NewWithVectors(false),
+		})
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[string]bool, len(points))
+		var noteIDs []string
+		for _, p := range points {
+			id := p.GetPayload()["note_id"].GetStringValue()
+			if id != "" && !seen[id] {
+				seen[id] = true
+				noteIDs = append(noteIDs, id)
+			}
+		}
+		if len(noteIDs) > 0 {
+			if err := fn(noteIDs); err != nil {
+				return err
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+		offset = next
+	}
+}
+
+// userFilter matches the points of a user's notes that pass f. The same
+// filter is applied to the MongoDB fetch (db.NoteQuery), so points whose
+// payload is stale can't widen a search result.
+func userFilter(userID string, f models.NoteFilter) *qdrantpb.Filter {
+	conds := []*qdrantpb.Condition{qdrantpb.NewMatch("user_id", userID)}
+	if f.NotebookID != "" {
+		conds = append(conds, qdrantpb.NewMatch("notebook_id", f.NotebookID))
+	}
+	for _, tag := range models.NormalizeTags(f.Tags) {
+		conds = append(conds, qdrantpb.NewMatch("tags", tag))
+	}
+	if len(f.NoteIDs) > 0 {
+		conds = append(conds, qdrantpb.NewMatchKeywords("note_id", f.NoteIDs...))
+	}
+	if r := datetimeRange(f.CreatedAfter, f.CreatedBefore); r != nil {
+		conds = append(conds, qdrantpb.NewDatetimeRange("created_at", r))
+	}
+	if r := datetimeRange(f.ModifiedAfter, f.ModifiedBefore); r != nil {
+		conds = append(conds, qdrantpb.NewDatetimeRange("modified_at", r))
+	}
+	return &qdrantpb.Filter{Must: conds}
+}
+
+func datetimeRange(after, before *time.Time) *qdrantpb.DatetimeRange {
+	if after == nil && before == nil {
+		return nil
+	}
+	r := &qdrantpb.DatetimeRange{}
+	if after != nil {
+		r.Gte = timestamppb.New(*after)
+	}
+	if before != nil {
+		r.Lt = timestamppb.New(*before)
+	}
+	return r
+}
+
+func denseVector(v *qdrantpb.VectorsOutput) []float32 {
+	out := v.GetVector()
+	if dense := out.GetDense(); dense != nil {
+		return dense.GetData()
+	}
+	return out.GetData()
+}
@@ -0,0 +1,159 @@
+// Package vectorstore holds the chunk vectors of notes. Qdrant is the
+// default store; the "local" store keeps vectors in process and in a file
+// for small deployments and tests that shouldn't need a Qdrant server.
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"note-llm/internal/models"
+
+	"github.com/spf13/viper"
+)
+
+// ChunkPoint is the vector for one chunk of a note, with the byte range of
+// the note content it was computed from.
+type ChunkPoint struct {
+	Index  int
+	Start  int
+	End    int
+	Vector []float32
+}
+
+// NotePayload is the note metadata stored with every chunk point so that
+// queries can be filtered by it.
+type NotePayload struct {
+	NoteID     string
+	UserID     string
+	NotebookID string
+	Tags       []string
+	CreatedAt  time.Time
+	ModifiedAt time.Time
+	// Version is the note version the chunk offsets were computed from.
+	Version int64
+}
+
+// Query asks for the chunks of a user's notes closest to Vector.
+type Query struct {
+	UserID string
+	Vector []float32
+	// Filter narrows the search down to some of the user's notes.
+	Filter models.NoteFilter
+	// Limit caps the number of hits; 0 leaves it to the store's default.
+	Limit int
+	// MinScore drops hits with a lower similarity score when non-zero.
+	MinScore float32
+	// WithVectors fills in Hit.Vector.
+	WithVectors bool
+}
+
+// Hit is a matching chunk of a note. Start/End are byte offsets into the
+// content of version NoteVersion of the note; both are zero for legacy
+// whole-note points, and NoteVersion is zero for points indexed before
+// versions were recorded.
+type Hit struct {
+	NoteID      string
+	NoteVersion int64
+	ChunkIndex  int
+	Start       int
+	End         int
+	Score       float32
+	// Vector is only populated when the query asks for it.
+	Vector []float32
+}
+
+// VectorStore stores chunk vectors with their note's metadata and finds
+// the ones closest to a query vector by cosine similarity.
+type VectorStore interface {
+	// Upsert stores one point per chunk and removes any other points of
+	// the note, such as chunks past the end of a note that got shorter.
+	Upsert(ctx context.Context, note NotePayload, chunks []ChunkPoint) error
+	// Delete removes every point (all chunks) of the given notes.
+	Delete(ctx context.Context, noteIDs ...string) error
+	// Query returns the closest matching chunks, best first.
+	Query(ctx context.Context, q Query) ([]Hit, error)
+	// Count returns how many points of a user's notes match the filter.
+	Count(ctx context.Context, userID string, f models.NoteFilter) (uint64, error)
+
+	// ClearNotebook removes a deleted notebook's ID from the points of a
+	// user's notes, matching what db.DeleteNotebook does to the notes.
+	ClearNotebook(ctx context.Context, userID, notebookID string) error
+	// NoteChunkVectors returns the chunk vectors of a user's notes, keyed
+	// by note ID and in chunk order. Notes without points are missing from
+	// the map.
+	NoteChunkVectors(ctx context.Context, userID string, noteIDs []string) (map[string][][]float32, error)
+	// ScrollNoteIDs walks all points page by page and calls fn with the
+	// distinct note IDs found on each page. A note whose chunks span two
+	// pages may be reported twice.
+	ScrollNoteIDs(ctx context.Context, pageSize int, fn func(noteIDs []string) error) error
+	// ScrollLegacyNoteIDs is ScrollNoteIDs limited to notes that still have
+	// a point from an older indexer: a whole-note point from before notes
+	// were chunked, which lacks the chunk index, offsets and the metadata
+	// that filters match on, or a chunk without the note version its
+	// offsets belong to.
+	ScrollLegacyNoteIDs(ctx context.Context, pageSize int, fn func(noteIDs []string) error) error
+}
+
+// Factory builds a VectorStore from the current viper config.
+type Factory func() (VectorStore, error)
+
+var (
+	storesMu sync.RWMutex
+	stores   = map[string]Factory{}
+
+	defaultStore     VectorStore
+	defaultStoreOnce sync.Once
+	defaultStoreErr  error
+)
+
+// Register makes a vector store selectable through the VECTOR_STORE config
+// key. It panics on duplicate names.
+func Register(name string, factory Factory) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	name = strings.ToLower(name)
+	if _, exists := stores[name]; exists {
+		panic(fmt.Sprintf("vectorstore: store %q already registered", name))
+	}
+	stores[name] = factory
+}
+
+// New builds the registered vector store with the given name.
+func New(name string) (VectorStore, error) {
+	storesMu.RLock()
+	factory, ok := stores[strings.ToLower(name)]
+	storesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown vector store %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return factory()
+}
+
+// Names lists the registered vector stores.
+func Names() []string {
+	storesMu.RLock()
+	defer storesMu.RUnlock()
+
+	names := make([]string, 0, len(stores))
+	for name := range stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default returns the store selected by VECTOR_STORE (defaults to
+// "qdrant"). It is built once and shared.
+func Default() (VectorStore, error) {
+	defaultStoreOnce.Do(func() {
+		viper.SetDefault("VECTOR_STORE", "qdrant")
+		defaultStore, defaultStoreErr = New(viper.GetString("VECTOR_STORE"))
+	})
+	return defaultStore, defaultStoreErr
+}
@@ -1,18 +1,21 @@
 import { useState, useEffect } from 'react';
 import { Note, CreateNoteRequest, UpdateNoteRequest } from '../types';
-import { notesAPI } from '../lib/api';
+import { notesAPI, isVersionConflict } from '../lib/api';
 import { toast } from 'react-hot-toast';
 
 export const useNotes = () => {
   const [notes, setNotes] = useState<Note[]>([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
+  const [nextCursor, setNextCursor] = useState<string | undefined>();
+  const [loadingMore, setLoadingMore] = useState(false);
 
   const fetchNotes = async () => {
     try {
       setLoading(true);
-      const fetchedNotes = await notesAPI.getAllNotes();
-      setNotes(fetchedNotes);
+      const page = await notesAPI.getNotes();
+      setNotes(page.notes);
+      setNextCursor(page.next_cursor);
       setError(null);
     } catch (err) {
       setError('Failed to fetch notes');
@@ -22,6 +25,21 @@ export const useNotes = () => {
     }
   };
 
+  const loadMore = async () => {
+    if (!nextCursor || loadingMore) return;
+    try {
+      setLoadingMore(true);
+      const page = await notesAPI.getNotes({ cursor: nextCursor });
+      setNotes(prev => [...prev, ...page.notes.filter(n => !prev.some(p => p.id === n.id))]);
+      setNextCursor(page.next_cursor);
+    } catch (err) {
+      toast.error('Failed to load more notes');
+      console.error('Error fetching notes:', err);
+    } finally {
+      setLoadingMore(false);
+    }
+  };
+
   const createNote = async (noteData: CreateNoteRequest) => {
     try {
       const newNote = await notesAPI.createNote(noteData);
@@ -34,27 +52,43 @@ export const useNotes = () => {
     }
   };
 
+  const versionOf = (id: string) => notes.find(note => note.id === id)?.version ?? 0;
+
+  // A 412 means the note was changed elsewhere; show the server copy so the
+  // edit isn't applied on top of a version the user never saw.
+  const handleConflict = (id: string, err: unknown) => {
+    if (!isVersionConflict(err) || !err.response) return false;
+    const serverNote = err.response.data;
+    setNotes(prev => prev.map(note => note.id === id ? serverNote : note));
+    toast.error('This note was changed elsewhere. Reloaded the latest version.');
+    return true;
+  };
+
   const updateNote = async (id: string, noteData: UpdateNoteRequest) => {
     try {
-      const updatedNote = await notesAPI.updateNote(id, noteData);
+      const updatedNote = await notesAPI.updateNote(id, noteData, versionOf(id));
       setNotes(prev => prev.map(note => 
         note.id === id ? updatedNote : note
       ));
       toast.success('Note updated successfully!');
       return updatedNote;
     } catch (err) {
-      toast.error('Failed to update note');
+      if (!handleConflict(id, err)) {
+        toast.error('Failed to update note');
+      }
       throw err;
     }
   };
 
   const deleteNote = async (id: string) => {
     try {
-      await notesAPI.deleteNote(id);
+      await notesAPI.deleteNote(id, versionOf(id));
       setNotes(prev => prev.filter(note => note.id !== id));
       toast.success('Note deleted successfully!');
     } catch (err) {
-      toast.error('Failed to delete note');
+      if (!handleConflict(id, err)) {
+        toast.error('Failed to delete note');
+      }
       throw err;
     }
   };
@@ -67,6 +101,9 @@ export const useNotes = () => {
     notes,
     loading,
     error,
+    hasMore: !!nextCursor,
+    loadingMore,
+    loadMore,
     createNote,
     updateNote,
     deleteNote,
@@ -1,5 +1,5 @@
-import axios from 'axios';
-import { Note, CreateNoteRequest, UpdateNoteRequest } from '../types';
+import axios, { AxiosError } from 'axios';
+import { Note, CreateNoteRequest, UpdateNoteRequest, NoteIndexStatus, Notebook, NoteListParams, NotePage, AskScope, NoteRevision, NoteDiff } from '../types';
 
 // Configure your backend API base URL
 const API_BASE_URL = 'http://localhost:8080'; // Update this to your backend URL
@@ -40,9 +40,17 @@ export const authAPI = {
   },
 };
 
+const ifMatch = (version: number) => ({ 'If-Match': `"${version}"` });
+
+// isVersionConflict reports whether a write to a note failed because it
+// changed elsewhere; the error's response then holds the server copy.
+export const isVersionConflict = (err: unknown): err is AxiosError<Note> =>
+  axios.isAxiosError(err) && err.response?.status === 412;
+
 export const notesAPI = {
-  getAllNotes: async (): Promise<Note[]> => {
-    const response = await api.get('/notes');
+  getNotes: async ({ tags, ...rest }: NoteListParams = {}): Promise<NotePage> => {
+    const params = { ...rest, tags: tags?.length ? tags.join(',') : undefined };
+    const response = await api.get('/notes', { params });
     return response.data;
   },
 
@@ -51,23 +59,80 @@ export const notesAPI = {
     return response.data;
   },
 
-  updateNote: async (id: string, note: UpdateNoteRequest): Promise<Note> => {
-    const response = await api.put(`/notes/${id}`, note);
+  updateNote: async (id: string, note: UpdateNoteRequest, version: number): Promise<Note> => {
+    const response = await api.put(`/notes/${id}`, note, { headers: ifMatch(version) });
     return response.data;
   },
 
-  deleteNote: async (id: string): Promise<void> => {
-    await api.delete(`/notes/${id}`);
+  deleteNote: async (id: string, version: number): Promise<void> => {
+    await api.delete(`/notes/${id}`, { headers: ifMatch(version) });
   },
 
   getNote: async (id: string): Promise<Note> => {
     const response = await api.get(`/notes/${id}`);
     return response.data;
   },
-  askQuestion: async (question: string): Promise<string> => {
-    const response = await api.post('/notes/ask', { question });
+  getIndexStatus: async (id: string): Promise<NoteIndexStatus> => {
+    const response = await api.get(`/notes/${id}/index-status`);
+    return response.data;
+  },
+  getRevisions: async (id: string): Promise<NoteRevision[]> => {
+    const response = await api.get(`/notes/${id}/revisions`);
+    return response.data;
+  },
+  getRevision: async (id: string, rev: number): Promise<NoteRevision> => {
+    const response = await api.get(`/notes/${id}/revisions/${rev}`);
+    return response.data;
+  },
+  diffRevisions: async (id: string, from: number | 'current', to: number | 'current' = 'current'): Promise<NoteDiff> => {
+    const response = await api.get(`/notes/${id}/revisions/diff`, { params: { from, to } });
+    return response.data;
+  },
+  restoreRevision: async (id: string, rev: number, version: number): Promise<Note> => {
+    const response = await api.post(`/notes/${id}/revisions/${rev}/restore`, undefined, { headers: ifMatch(version) });
+    return response.data;
+  },
+  askQuestion: async (question: string, scope: AskScope = {}): Promise<string> => {
+    const response = await api.post('/notes/ask', { question, ...scope });
     return response.data.answer;
   },
 };
 
+export const notebooksAPI = {
+  getAllNotebooks: async (): Promise<Notebook[]> => {
+    const response = await api.get('/notebooks');
+    return response.data;
+  },
+
+  createNotebook: async (name: string): Promise<Notebook> => {
+    const response = await api.post('/notebooks', { name });
+    return response.data;
+  },
+
+  renameNotebook: async (id: string, name: string): Promise<Notebook> => {
+    const response = await api.put(`/notebooks/${id}`, { name });
+    return response.data;
+  },
+
+  deleteNotebook: async (id: string): Promise<void> => {
+    await api.delete(`/notebooks/${id}`);
+  },
+};
+
+export const trashAPI = {
+  getTrash: async (): Promise<Note[]> => {
+    const response = await api.get('/trash');
+    return response.data;
+  },
+
+  restoreNote: async (id: string, version: number): Promise<Note> => {
+    const response = await api.post(`/trash/${id}/restore`, undefined, { headers: ifMatch(version) });
+    return response.data;
+  },
+
+  purgeNote: async (id: string, version: number): Promise<void> => {
+    await api.delete(`/trash/${id}`, { headers: ifMatch(version) });
+  },
+};
+
 export default api;
\ No newline at end of file
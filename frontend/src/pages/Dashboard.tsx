@@ -9,7 +9,7 @@ import { useNotes } from '../hooks/useNotes';
 import { Note, CreateNoteRequest, UpdateNoteRequest } from '../types';
 
 const Dashboard: React.FC = () => {
-  const { notes, loading, createNote, updateNote, deleteNote } = useNotes();
+  const { notes, loading, hasMore, loadingMore, loadMore, createNote, updateNote, deleteNote } = useNotes();
   const [isEditorOpen, setIsEditorOpen] = useState(false);
   const [editingNote, setEditingNote] = useState<Note | undefined>();
   const [searchTerm, setSearchTerm] = useState('');
@@ -166,6 +166,20 @@ const Dashboard: React.FC = () => {
             </motion.div>
           )}
         </AnimatePresence>
+
+        {hasMore && (
+          <div className="flex justify-center mt-8">
+            <motion.button
+              onClick={loadMore}
+              disabled={loadingMore}
+              className="btn-primary"
+              whileHover={{ scale: 1.05 }}
+              whileTap={{ scale: 0.95 }}
+            >
+              {loadingMore ? 'Loading...' : 'Load more'}
+            </motion.button>
+          </div>
+        )}
       </main>
 
       {/* Note Editor Modal */}
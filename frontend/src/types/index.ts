@@ -3,18 +3,100 @@ export interface Note {
   title: string;
   content: string;
   user_id: string;
+  notebook_id?: string;
+  tags?: string[];
   created_at: string;
   modified_at: string;
+  index_status?: IndexStatus;
+  index_error?: string;
+  indexed_at?: string;
+  // Set while the note is in the trash.
+  deleted_at?: string;
+  // Bumped on every edit; sent back as If-Match when saving or deleting.
+  version: number;
+  // Only present when requested with ?include=embedding.
+  embedding?: number[];
+}
+
+export type IndexStatus = 'pending' | 'indexed' | 'failed';
+
+export interface NoteIndexStatus {
+  note_id: string;
+  index_status: IndexStatus;
+  index_error?: string;
+  indexed_at?: string;
+  modified_at: string;
+}
+
+export interface NoteRevision {
+  id: string;
+  note_id: string;
+  number: number;
+  title: string;
+  content?: string;
+  notebook_id?: string;
+  tags?: string[];
+  modified_at: string;
+  created_at: string;
+}
+
+export interface NoteDiff {
+  from: string;
+  to: string;
+  from_title: string;
+  to_title: string;
+  lines: { op: 'equal' | 'insert' | 'delete'; text: string }[];
 }
 
 export interface CreateNoteRequest {
   title: string;
   content: string;
+  notebook_id?: string;
+  tags?: string[];
 }
 
 export interface UpdateNoteRequest {
   title: string;
   content: string;
+  notebook_id?: string;
+  tags?: string[];
+}
+
+export interface Notebook {
+  id: string;
+  user_id: string;
+  name: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface NoteFilter {
+  notebook_id?: string;
+  tags?: string[];
+}
+
+export type NoteSort = 'created' | 'modified' | 'title';
+
+export interface NoteListParams extends NoteFilter {
+  sort?: NoteSort;
+  order?: 'asc' | 'desc';
+  limit?: number;
+  cursor?: string;
+}
+
+export interface NotePage {
+  notes: Note[];
+  next_cursor?: string;
+}
+
+// Filters accepted by /notes/ask. Dates are RFC 3339; "after" bounds are
+// inclusive and "before" bounds exclusive.
+export interface AskScope extends NoteFilter {
+  note_ids?: string[];
+  created_after?: string;
+  created_before?: string;
+  modified_after?: string;
+  modified_before?: string;
 }
 
 export interface User {